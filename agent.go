@@ -0,0 +1,333 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxIterations    = 10
+	defaultMaxRepeatedCalls = 3
+)
+
+var (
+	// ErrMaxIterations is returned by Agent.Run when the model still asks
+	// for tools after the maximum number of iterations.
+	ErrMaxIterations = errors.New("maximum number of tool iterations reached")
+	// ErrToolLoop is returned by Agent.Run when the model keeps calling a
+	// tool with the same arguments.
+	ErrToolLoop = errors.New("tool called repeatedly with the same arguments")
+)
+
+// ToolExecutor runs tools by name. McpClient and ToolRegistry implement it,
+// so the tools they list can be run by an Agent.
+type ToolExecutor interface {
+	CallToolContext(ctx context.Context, name string, arguments map[string]any) (string, error)
+}
+
+// Agent runs the tool-calling loop: it sends the prompt with the tools of
+// its sources, runs the tools the model asks for, sends their results back
+// and repeats until the model answers in plain text.
+//
+// Every source must also be a ToolExecutor, which runs the tools it lists.
+type Agent struct {
+	// MaxIterations is the maximum number of requests to the model
+	// (default 10).
+	MaxIterations int
+	// ToolTimeout limits the time each tool call can take. 0 means no limit
+	// other than the context of Run.
+	ToolTimeout time.Duration
+	// MaxRepeatedCalls is how many times the same tool can be called with
+	// the same arguments before Run gives up with ErrToolLoop (default 3).
+	MaxRepeatedCalls int
+	// OnStep is called after every step, e.g. to log the progress.
+	OnStep func(AgentStep)
+	// DefaultPolicy applies to the tools without a policy set with
+	// SetPolicy or SetSourcePolicy (default PolicyAllow).
+	DefaultPolicy ToolPolicy
+	// Approve is asked about the calls of tools with PolicyAsk. Without it,
+	// those calls are denied. It is never called concurrently.
+	Approve ApprovalFunc
+	// MaxParallelTools is how many tool calls of the same answer can run at
+	// once (default 1, one after another). Results are sent to the model in
+	// the order of the calls either way.
+	MaxParallelTools int
+	// CancelOnError cancels the other tool calls of the same answer when
+	// one fails. Their results are still sent to the model.
+	CancelOnError bool
+
+	client         *Gollama
+	sources        []ToolSource
+	policies       map[string]ToolPolicy
+	sourcePolicies []sourcePolicy
+	approveMu      sync.Mutex
+}
+
+// AgentStep is one iteration of the agent loop: an answer of the model and
+// the results of the tools it called.
+type AgentStep struct {
+	Iteration   int
+	Output      *ChatOuput
+	ToolResults []ToolResult
+}
+
+// ToolResult is the outcome of a tool call.
+type ToolResult struct {
+	Call ToolCall
+	// Arguments are the arguments the tool ran with, after validation and
+	// approval.
+	Arguments map[string]any
+	Result    string
+	// Err is the error of the call, sent to the model instead of a result.
+	// It wraps ErrToolDenied if the call was denied.
+	Err      error
+	Duration time.Duration
+}
+
+// AgentResult is the outcome of Agent.Run.
+type AgentResult struct {
+	// Output is the final answer of the model.
+	Output *ChatOuput
+	// Messages is the whole exchange, including tool calls and results.
+	Messages []Message
+	// Steps is the trace of every iteration.
+	Steps []AgentStep
+}
+
+// NewAgent creates an Agent that offers the tools of the given sources to
+// the model.
+func (c *Gollama) NewAgent(sources ...ToolSource) *Agent {
+	return &Agent{
+		MaxIterations:    defaultMaxIterations,
+		MaxRepeatedCalls: defaultMaxRepeatedCalls,
+		client:           c,
+		sources:          sources,
+	}
+}
+
+// RunWithTools answers the prompt, running the tools of the sources the
+// model asks for, with the default settings of NewAgent.
+func (c *Gollama) RunWithTools(ctx context.Context, prompt string, sources []ToolSource, options ...ChatOption) (*AgentResult, error) {
+	return c.NewAgent(sources...).Run(ctx, prompt, options...)
+}
+
+// Run answers the prompt, running the tools the model asks for until it
+// answers in plain text.
+//
+// Options are the same as for Chat. ToolSource options are added to the
+// sources of the agent. The arguments of every call are checked with
+// ValidateArguments. A failing tool or invalid arguments do not stop the
+// loop: the error is sent to the model as the result, so it can recover.
+//
+// If the loop fails, the result so far is returned along with the error,
+// so the trace can be inspected.
+func (a *Agent) Run(ctx context.Context, prompt string, options ...ChatOption) (*AgentResult, error) {
+	messages, options := a.client.promptMessages(prompt, options)
+
+	sources := append([]ToolSource{}, a.sources...)
+	chatOptions := make([]ChatOption, 0, len(options))
+	for _, option := range options {
+		if source, ok := option.(ToolSource); ok {
+			sources = append(sources, source)
+			continue
+		}
+		chatOptions = append(chatOptions, option)
+	}
+
+	tools, executors, err := collectTools(sources)
+	if err != nil {
+		return nil, err
+	}
+	for _, tool := range tools {
+		chatOptions = append(chatOptions, tool)
+	}
+
+	maxIterations := a.MaxIterations
+	if maxIterations <= 0 {
+		maxIterations = defaultMaxIterations
+	}
+	maxRepeated := a.MaxRepeatedCalls
+	if maxRepeated <= 0 {
+		maxRepeated = defaultMaxRepeatedCalls
+	}
+
+	result := &AgentResult{Messages: messages}
+	seen := map[string]int{}
+
+	for iteration := 1; iteration <= maxIterations; iteration++ {
+		out, err := a.client.chat(ctx, result.Messages, chatOptions)
+		if err != nil {
+			return result, err
+		}
+
+		result.Messages = append(result.Messages, Message{
+			Role:      RoleAssistant,
+			Content:   out.Content,
+			ToolCalls: out.ToolCalls,
+		})
+
+		step := AgentStep{Iteration: iteration, Output: out}
+
+		if len(out.ToolCalls) == 0 {
+			result.Output = out
+			a.addStep(result, step)
+			return result, nil
+		}
+
+		for _, call := range out.ToolCalls {
+			key := callKey(call)
+			seen[key]++
+			if seen[key] > maxRepeated {
+				a.addStep(result, step)
+				return result, fmt.Errorf("%w: %s", ErrToolLoop, call.Function.Name)
+			}
+		}
+
+		step.ToolResults, err = a.runTools(ctx, executors, out.ToolCalls)
+		for _, res := range step.ToolResults {
+			result.Messages = append(result.Messages, res.message())
+		}
+
+		a.addStep(result, step)
+
+		if err != nil {
+			return result, err
+		}
+
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+	}
+
+	return result, ErrMaxIterations
+}
+
+func (a *Agent) addStep(result *AgentResult, step AgentStep) {
+	result.Steps = append(result.Steps, step)
+	if a.OnStep != nil {
+		a.OnStep(step)
+	}
+}
+
+// runTools runs the tool calls of an answer, up to MaxParallelTools at
+// once, and returns their results in order. Calls that had not started when
+// the calls were cancelled are not run.
+func (a *Agent) runTools(ctx context.Context, executors map[string]sourceTool, calls []ToolCall) ([]ToolResult, error) {
+	results := make([]ToolResult, len(calls))
+
+	err := runConcurrently(ctx, len(calls), a.MaxParallelTools, a.CancelOnError, func(ctx context.Context, i int) (bool, error) {
+		if err := ctx.Err(); err != nil {
+			results[i] = ToolResult{Call: calls[i], Err: err}
+			return false, nil
+		}
+
+		res, err := a.runTool(ctx, executors, calls[i])
+		results[i] = res
+
+		return res.Err != nil && !errors.Is(res.Err, ErrToolDenied), err
+	})
+
+	return results, err
+}
+
+// runTool runs a tool call with the executor of its tool. The arguments
+// are validated first, so the model gets a clear error to correct them,
+// and then the call is approved according to the policy of the tool.
+//
+// Errors of the call are in the result. The returned error is fatal and
+// stops the agent.
+func (a *Agent) runTool(ctx context.Context, executors map[string]sourceTool, call ToolCall) (ToolResult, error) {
+	res := ToolResult{Call: call}
+
+	source, ok := executors[call.Function.Name]
+	if !ok {
+		res.Err = fmt.Errorf("unknown tool %q", call.Function.Name)
+		return res, nil
+	}
+
+	arguments, err := ValidateArguments(call.Function.Arguments, source.tool.Function.Parameters)
+	if err != nil {
+		res.Err = fmt.Errorf("invalid arguments for tool %q: %w", call.Function.Name, err)
+		return res, nil
+	}
+
+	arguments, err = a.approve(ctx, source, call, arguments)
+	if errors.Is(err, ErrToolDenied) {
+		res.Err = err
+		return res, nil
+	}
+	if err != nil {
+		return res, err
+	}
+	res.Arguments = arguments
+
+	if a.ToolTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.ToolTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	res.Result, res.Err = source.executor.CallToolContext(ctx, call.Function.Name, arguments)
+	res.Duration = time.Since(start)
+
+	return res, nil
+}
+
+// message returns the tool message that sends the result to the model.
+func (r ToolResult) message() Message {
+	content := r.Result
+	if r.Err != nil {
+		content = "error: " + r.Err.Error()
+	}
+
+	return toolMessage(r.Call, content)
+}
+
+// sourceTool is a tool and the executor that runs it.
+type sourceTool struct {
+	tool     Tool
+	source   ToolSource
+	executor ToolExecutor
+}
+
+// collectTools lists the tools of every source, and maps each tool name to
+// the source that runs it.
+func collectTools(sources []ToolSource) ([]Tool, map[string]sourceTool, error) {
+	tools := []Tool{}
+	executors := map[string]sourceTool{}
+
+	for _, source := range sources {
+		executor, ok := source.(ToolExecutor)
+		if !ok {
+			return nil, nil, fmt.Errorf("tool source %T can not run tools", source)
+		}
+
+		listed, err := source.ListTools()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, tool := range listed {
+			name := tool.Function.Name
+			if _, ok := executors[name]; ok {
+				return nil, nil, fmt.Errorf("tool %q is provided by more than one source", name)
+			}
+			executors[name] = sourceTool{tool: tool, source: source, executor: executor}
+			tools = append(tools, tool)
+		}
+	}
+
+	return tools, executors, nil
+}
+
+// callKey identifies a tool call by its name and arguments. Maps are
+// encoded with sorted keys, so equal arguments give equal keys.
+func callKey(call ToolCall) string {
+	arguments, _ := json.Marshal(call.Function.Arguments)
+	return call.Function.Name + "\x00" + string(arguments)
+}
@@ -0,0 +1,210 @@
+package gollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestAgent_Run(t *testing.T) {
+	type addArgs struct {
+		A int `json:"a" required:"true"`
+		B int `json:"b" required:"true"`
+	}
+
+	registry := NewToolRegistry()
+	RegisterTool(registry, "add", "Add two numbers", func(ctx context.Context, args addArgs) (int, error) {
+		return args.A + args.B, nil
+	})
+	RegisterTool(registry, "slow", "Never finishes in time", func(ctx context.Context, args struct{}) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+
+	toolCall := func(name string, arguments map[string]any) chatResponse {
+		return chatResponse{
+			Model:   "test",
+			Message: messageResponse{Role: "assistant", ToolCalls: []ToolCall{{Function: ToolCallFunction{Name: name, Arguments: arguments}}}},
+			Done:    true,
+		}
+	}
+	answer := chatResponse{Model: "test", Message: messageResponse{Role: "assistant", Content: "The answer is 3"}, Done: true}
+
+	tests := []struct {
+		name        string
+		agent       func(a *Agent)
+		reply       func(n int) chatResponse
+		wantErr     error
+		wantSteps   int
+		wantContent string
+		wantTool    string // content of the first tool message
+	}{
+		{
+			name: "Tool call then answer",
+			reply: func(n int) chatResponse {
+				if n == 1 {
+					return toolCall("add", map[string]any{"a": 1.0, "b": "2"})
+				}
+				return answer
+			},
+			wantSteps:   2,
+			wantContent: "The answer is 3",
+			wantTool:    "3",
+		},
+		{
+			name: "Invalid arguments are sent to the model",
+			reply: func(n int) chatResponse {
+				if n == 1 {
+					return toolCall("add", map[string]any{"a": "one"})
+				}
+				return answer
+			},
+			wantSteps:   2,
+			wantContent: "The answer is 3",
+			wantTool:    `error: invalid arguments for tool "add": a: expected integer, got string; b: missing required field`,
+		},
+		{
+			name: "Tool timeout is sent to the model",
+			agent: func(a *Agent) {
+				a.ToolTimeout = 10 * time.Millisecond
+			},
+			reply: func(n int) chatResponse {
+				if n == 1 {
+					return toolCall("slow", nil)
+				}
+				return answer
+			},
+			wantSteps:   2,
+			wantContent: "The answer is 3",
+			wantTool:    "error: " + context.DeadlineExceeded.Error(),
+		},
+		{
+			name: "Repeated call",
+			agent: func(a *Agent) {
+				a.MaxRepeatedCalls = 2
+			},
+			reply: func(n int) chatResponse {
+				return toolCall("add", map[string]any{"a": 1.0, "b": 2.0})
+			},
+			wantErr:   ErrToolLoop,
+			wantSteps: 3,
+			wantTool:  "3",
+		},
+		{
+			name: "Max iterations",
+			agent: func(a *Agent) {
+				a.MaxIterations = 2
+			},
+			reply: func(n int) chatResponse {
+				return toolCall("add", map[string]any{"a": float64(n), "b": 0.0})
+			},
+			wantErr:   ErrMaxIterations,
+			wantSteps: 2,
+			wantTool:  "1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []chatRequest
+			c := newTestServer(t, "test", func(w http.ResponseWriter, r *http.Request) {
+				var req chatRequest
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Fatal(err)
+				}
+				requests = append(requests, req)
+				json.NewEncoder(w).Encode(tt.reply(len(requests)))
+			})
+
+			agent := c.NewAgent(registry)
+			if tt.agent != nil {
+				tt.agent(agent)
+			}
+
+			steps := 0
+			agent.OnStep = func(AgentStep) { steps++ }
+
+			result, err := agent.Run(context.Background(), "What is 1 + 2?")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Agent.Run() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if len(result.Steps) != tt.wantSteps || steps != tt.wantSteps {
+				t.Errorf("steps = %d (OnStep %d), want %d", len(result.Steps), steps, tt.wantSteps)
+			}
+			if tt.wantErr == nil && result.Output.Content != tt.wantContent {
+				t.Errorf("Output.Content = %q, want %q", result.Output.Content, tt.wantContent)
+			}
+
+			if requests[0].Tools == nil || len(*requests[0].Tools) != 2 {
+				t.Errorf("the tools of the registry were not sent")
+			}
+
+			gotTool := ""
+			for _, m := range result.Messages {
+				if m.Role == RoleTool {
+					gotTool = m.Content
+					break
+				}
+			}
+			if gotTool != tt.wantTool {
+				t.Errorf("tool message = %q, want %q", gotTool, tt.wantTool)
+			}
+		})
+	}
+}
+
+func TestAgent_RunWithReaderImage(t *testing.T) {
+	registry := NewToolRegistry()
+	RegisterTool(registry, "look", "Look closer", func(ctx context.Context, args struct{}) (string, error) {
+		return "a road", nil
+	})
+
+	road, err := os.ReadFile("./test/road.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var images [][]string
+	c := newTestServer(t, "test", func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		images = append(images, req.Messages[0].Images)
+
+		resp := chatResponse{Model: "test", Message: messageResponse{Role: "assistant", Content: "A road"}, Done: true}
+		if len(images) == 1 {
+			resp.Message = messageResponse{Role: "assistant", ToolCalls: []ToolCall{{Function: ToolCallFunction{Name: "look"}}}}
+		}
+		json.NewEncoder(w).Encode(resp)
+	})
+
+	result, err := c.NewAgent(registry).Run(context.Background(), "What is it?", ImageFromReader(bytes.NewReader(road)))
+	if err != nil {
+		t.Fatalf("Agent.Run() error = %v", err)
+	}
+	if result.Output.Content != "A road" {
+		t.Errorf("Output.Content = %q", result.Output.Content)
+	}
+	if len(images) != 2 || len(images[1]) != 1 || images[1][0] != images[0][0] {
+		t.Errorf("the image was not sent again on the second turn")
+	}
+}
+
+func TestCollectTools(t *testing.T) {
+	a := NewToolRegistry()
+	b := NewToolRegistry()
+	handler := func(ctx context.Context, arguments map[string]any) (string, error) { return "", nil }
+	a.Register(Tool{Function: ToolFunction{Name: "same"}}, handler)
+	b.Register(Tool{Function: ToolFunction{Name: "same"}}, handler)
+
+	if _, _, err := collectTools([]ToolSource{a, b}); err == nil {
+		t.Error("collectTools() should fail when two sources provide the same tool")
+	}
+}
@@ -1,6 +1,7 @@
 package gollama
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -130,3 +131,95 @@ func (c *Gollama) apiPost(ctx context.Context, path string, v interface{}, data
 
 	return nil
 }
+
+// apiPostStream sends a POST request to the specified path on the Ollama
+// server and calls fn for every line of the newline-delimited JSON response.
+//
+// The request is bound to ctx, so cancelling it closes the connection and
+// tells the server to stop generating. Reading stops as soon as fn returns
+// an error, and that error is returned.
+//
+// The Verbose flag is respected, and every received line is printed if it
+// is set.
+//
+// The HTTPTimeout is used as the timeout for the whole HTTP request,
+// including reading the streamed body.
+func (c *Gollama) apiPostStream(ctx context.Context, path string, data interface{}, fn func(line []byte) error) error {
+	url, _ := url.JoinPath(c.ServerAddr, path)
+	if c.Verbose {
+		fmt.Printf("Sending a streaming request to POST %s\nRequest body: %+v\n", url, data)
+	}
+
+	reqBytes, err := json.Marshal(data)
+	if err != nil {
+		if c.Verbose {
+			fmt.Printf("Failed to marshal request data: %s\n", err)
+		}
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/x-ndjson")
+
+	HTTPClient := &http.Client{
+		Timeout: c.HTTPTimeout,
+	}
+
+	resp, err := HTTPClient.Do(req)
+	if err != nil {
+		if c.Verbose {
+			fmt.Printf("Failed to send request: %s\n", err)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return apiError(resp)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, err := reader.ReadBytes('\n')
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			if c.Verbose {
+				fmt.Printf("Response chunk: %s\n", string(line))
+			}
+			if fnErr := fn(line); fnErr != nil {
+				return fnErr
+			}
+		}
+
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+	}
+}
+
+// apiError builds an error from a failed HTTP response. Ollama reports
+// errors as a JSON object with an "error" field; if the body is not in
+// that shape, the HTTP status is used instead.
+func apiError(resp *http.Response) error {
+	var body struct {
+		Error string `json:"error"`
+	}
+
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	if json.Unmarshal(bodyBytes, &body) == nil && body.Error != "" {
+		return fmt.Errorf("ollama: %s", body.Error)
+	}
+
+	return fmt.Errorf("ollama: unexpected status %s", resp.Status)
+}
@@ -0,0 +1,173 @@
+package gollama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrToolDenied is the error of a tool call that was not allowed to run.
+var ErrToolDenied = errors.New("tool call denied")
+
+// ToolPolicy decides whether an Agent may run a tool.
+type ToolPolicy int
+
+const (
+	// PolicyAllow runs the tool without asking.
+	PolicyAllow ToolPolicy = iota
+	// PolicyAsk runs the tool only if Agent.Approve approves the call.
+	PolicyAsk
+	// PolicyDeny never runs the tool. The model is told it was denied.
+	PolicyDeny
+)
+
+// ApprovalRequest describes a tool call waiting for approval.
+type ApprovalRequest struct {
+	Call ToolCall
+	// Name is the name of the tool.
+	Name string
+	// Arguments are the validated arguments the tool would run with.
+	Arguments map[string]any
+	// Source is the ToolSource that provides the tool, e.g. an McpClient or
+	// a ToolRegistry.
+	Source ToolSource
+}
+
+// ApprovalDecision is the answer to an ApprovalRequest.
+type ApprovalDecision struct {
+	Approved bool
+	// Reason tells the model why the call was denied.
+	Reason string
+	// Arguments, if not nil, replace the arguments of an approved call.
+	// They are validated again.
+	Arguments map[string]any
+}
+
+// ApprovalFunc decides whether a tool call may run. Returning an error stops
+// the agent.
+type ApprovalFunc func(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error)
+
+// Approve approves a tool call as it is.
+func Approve() ApprovalDecision {
+	return ApprovalDecision{Approved: true}
+}
+
+// ApproveWith approves a tool call with edited arguments.
+func ApproveWith(arguments map[string]any) ApprovalDecision {
+	return ApprovalDecision{Approved: true, Arguments: arguments}
+}
+
+// Deny denies a tool call. The reason is sent to the model.
+func Deny(reason string) ApprovalDecision {
+	return ApprovalDecision{Reason: reason}
+}
+
+type sourcePolicy struct {
+	source ToolSource
+	policy ToolPolicy
+}
+
+// SetPolicy sets the policy of a tool, by name. It takes precedence over
+// the policy of its source.
+func (a *Agent) SetPolicy(tool string, policy ToolPolicy) *Agent {
+	if a.policies == nil {
+		a.policies = map[string]ToolPolicy{}
+	}
+	a.policies[tool] = policy
+	return a
+}
+
+// SetSourcePolicy sets the policy of every tool of a source, e.g. to ask
+// before running any tool of an McpClient. Sources of a func type can not
+// be told apart: use SetPolicy for their tools.
+func (a *Agent) SetSourcePolicy(source ToolSource, policy ToolPolicy) *Agent {
+	a.sourcePolicies = append(a.sourcePolicies, sourcePolicy{source: source, policy: policy})
+	return a
+}
+
+// policyFor returns the policy of a tool: its own, else the one of its
+// source, else DefaultPolicy.
+func (a *Agent) policyFor(name string, source ToolSource) ToolPolicy {
+	if policy, ok := a.policies[name]; ok {
+		return policy
+	}
+	for i := len(a.sourcePolicies) - 1; i >= 0; i-- {
+		if sameSource(a.sourcePolicies[i].source, source) {
+			return a.sourcePolicies[i].policy
+		}
+	}
+	return a.DefaultPolicy
+}
+
+// sameSource reports whether a and b are the same tool source. Sources
+// that can not be compared with ==, like slices and maps, are the same
+// when they share their data.
+func sameSource(a, b ToolSource) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if !va.IsValid() || !vb.IsValid() || va.Type() != vb.Type() {
+		return !va.IsValid() && !vb.IsValid()
+	}
+
+	if va.Comparable() && vb.Comparable() {
+		return a == b
+	}
+
+	switch va.Kind() {
+	case reflect.Slice:
+		return va.Pointer() == vb.Pointer() && va.Len() == vb.Len()
+	case reflect.Map:
+		return va.Pointer() == vb.Pointer()
+	}
+	return false
+}
+
+// approve applies the policy of the tool to a call, and returns the
+// arguments to run it with. A denied call returns an error wrapping
+// ErrToolDenied, which is sent to the model. Other errors, from Approve or
+// for invalid edited arguments, stop the agent.
+func (a *Agent) approve(ctx context.Context, st sourceTool, call ToolCall, arguments map[string]any) (map[string]any, error) {
+	name := call.Function.Name
+
+	switch a.policyFor(name, st.source) {
+	case PolicyAllow:
+		return arguments, nil
+	case PolicyDeny:
+		return nil, fmt.Errorf("%w: tool %q is not allowed", ErrToolDenied, name)
+	}
+
+	if a.Approve == nil {
+		return nil, fmt.Errorf("%w: tool %q needs approval", ErrToolDenied, name)
+	}
+
+	a.approveMu.Lock()
+	decision, err := a.Approve(ctx, ApprovalRequest{
+		Call:      call,
+		Name:      name,
+		Arguments: arguments,
+		Source:    st.source,
+	})
+	a.approveMu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("error approving tool %q: %w", name, err)
+	}
+
+	if !decision.Approved {
+		reason := decision.Reason
+		if reason == "" {
+			reason = "denied by the user"
+		}
+		return nil, fmt.Errorf("%w: %s", ErrToolDenied, reason)
+	}
+
+	if decision.Arguments == nil {
+		return arguments, nil
+	}
+
+	edited, err := ValidateArguments(decision.Arguments, st.tool.Function.Parameters)
+	if err != nil {
+		return nil, fmt.Errorf("invalid edited arguments for tool %q: %w", name, err)
+	}
+
+	return edited, nil
+}
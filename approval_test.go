@@ -0,0 +1,158 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestAgent_Approval(t *testing.T) {
+	type writeArgs struct {
+		Path string `json:"path" required:"true"`
+	}
+
+	var written []string
+	files := NewToolRegistry()
+	RegisterTool(files, "write_file", "Write a file", func(ctx context.Context, args writeArgs) (string, error) {
+		written = append(written, args.Path)
+		return "written " + args.Path, nil
+	})
+
+	tests := []struct {
+		name        string
+		setup       func(a *Agent)
+		wantTool    string
+		wantWritten []string
+		wantErr     bool
+	}{
+		{
+			name:        "Allowed by default",
+			wantTool:    "written a.txt",
+			wantWritten: []string{"a.txt"},
+		},
+		{
+			name: "Denied by policy",
+			setup: func(a *Agent) {
+				a.SetPolicy("write_file", PolicyDeny)
+			},
+			wantTool: `error: tool call denied: tool "write_file" is not allowed`,
+		},
+		{
+			name: "Ask without approver",
+			setup: func(a *Agent) {
+				a.SetSourcePolicy(files, PolicyAsk)
+			},
+			wantTool: `error: tool call denied: tool "write_file" needs approval`,
+		},
+		{
+			name: "Denied by the user",
+			setup: func(a *Agent) {
+				a.DefaultPolicy = PolicyAsk
+				a.Approve = func(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error) {
+					if req.Source != files || req.Arguments["path"] != "a.txt" {
+						t.Errorf("unexpected approval request %+v", req)
+					}
+					return Deny("the user does not want it"), nil
+				}
+			},
+			wantTool: "error: tool call denied: the user does not want it",
+		},
+		{
+			name: "Approved with edited arguments",
+			setup: func(a *Agent) {
+				a.SetPolicy("write_file", PolicyAsk)
+				a.Approve = func(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error) {
+					return ApproveWith(map[string]any{"path": "b.txt"}), nil
+				}
+			},
+			wantTool:    "written b.txt",
+			wantWritten: []string{"b.txt"},
+		},
+		{
+			name: "Approver error stops the agent",
+			setup: func(a *Agent) {
+				a.SetPolicy("write_file", PolicyAsk)
+				a.Approve = func(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error) {
+					return ApprovalDecision{}, errors.New("no terminal")
+				}
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			written = nil
+			requests := 0
+			c := newTestServer(t, "test", func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				resp := chatResponse{Model: "test", Message: messageResponse{Role: "assistant", Content: "Done"}, Done: true}
+				if requests == 1 {
+					resp.Message = messageResponse{Role: "assistant", ToolCalls: []ToolCall{
+						{Function: ToolCallFunction{Name: "write_file", Arguments: map[string]any{"path": "a.txt"}}},
+					}}
+				}
+				json.NewEncoder(w).Encode(resp)
+			})
+
+			agent := c.NewAgent(files)
+			if tt.setup != nil {
+				tt.setup(agent)
+			}
+
+			result, err := agent.Run(context.Background(), "Write a file")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Agent.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(written) != len(tt.wantWritten) || (len(written) > 0 && written[0] != tt.wantWritten[0]) {
+				t.Errorf("written = %v, want %v", written, tt.wantWritten)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			got := result.Messages[len(result.Messages)-2]
+			if got.Role != RoleTool || got.Content != tt.wantTool {
+				t.Errorf("tool message = %+v, want %q", got, tt.wantTool)
+			}
+		})
+	}
+}
+
+// staticTools is a tool source that can not be compared with ==.
+type staticTools []Tool
+
+func (s staticTools) ListTools() ([]Tool, error) { return s, nil }
+
+func TestAgent_policyFor(t *testing.T) {
+	registry := NewToolRegistry()
+	static := staticTools{{Type: "function", Function: ToolFunction{Name: "now"}}}
+	other := staticTools{{Type: "function", Function: ToolFunction{Name: "later"}}}
+
+	agent := New("test").NewAgent()
+	agent.SetSourcePolicy(registry, PolicyDeny)
+	agent.SetSourcePolicy(static, PolicyAsk)
+	agent.SetPolicy("now", PolicyAllow)
+
+	tests := []struct {
+		name   string
+		tool   string
+		source ToolSource
+		want   ToolPolicy
+	}{
+		{name: "Tool policy first", tool: "now", source: static, want: PolicyAllow},
+		{name: "Source policy", tool: "then", source: static, want: PolicyAsk},
+		{name: "Comparable source", tool: "add", source: registry, want: PolicyDeny},
+		{name: "Other slice source", tool: "later", source: other, want: PolicyAllow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := agent.policyFor(tt.tool, tt.source); got != tt.want {
+				t.Errorf("policyFor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
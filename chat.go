@@ -2,8 +2,10 @@ package gollama
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 )
 
 type ChatOption interface{}
@@ -15,26 +17,126 @@ type ChatOption interface{}
 // The function takes a variable number of options as arguments. The options are:
 //   - A slice of strings representing the paths to images that should be passed as vision input.
 //   - A slice of Tool objects representing the tools that should be available to the model.
+//   - Options to override the model parameters for this call.
+//   - Think(true) or Think(false) to enable or disable the reasoning of thinking models.
+//   - A KeepAlive duration to control how long the model stays loaded.
+//   - Logprobs to return the log-probability of every generated token.
+//   - ImagePreprocess to resize, convert or tile the images before sending them.
+//   - Frames of an animation or video, sent as images with their timestamps.
+//   - A StructuredFormat, FormatJSON (or "json") or a json.RawMessage schema to constrain the output.
 //
 // The function returns a pointer to a ChatOuput object, which contains the response to the prompt,
 // as well as some additional information about the response. If an error occurs, the function
 // returns nil and an error.
 func (c *Gollama) Chat(ctx context.Context, prompt string, options ...ChatOption) (*ChatOuput, error) {
+	messages, options := c.promptMessages(prompt, options)
+	return c.chat(ctx, messages, options)
+}
+
+// promptMessages builds the messages for a single-turn prompt: the system
+// prompt, if any, followed by the user prompt with the images found in
+// options. The remaining options are returned.
+func (c *Gollama) promptMessages(prompt string, options []ChatOption) ([]Message, []ChatOption) {
+	prompt, images, options := splitPromptImages(prompt, options)
+
+	messages := []Message{}
+	if c.SystemPrompt != "" {
+		messages = append(messages, Message{
+			Role:    RoleSystem,
+			Content: c.SystemPrompt,
+		})
+	}
+
+	messages = append(messages, Message{
+		Role:    RoleUser,
+		Content: prompt,
+		Images:  images,
+	})
+
+	return messages, options
+}
+
+// splitPromptImages separates the PromptImage and Frames options from the
+// rest. Frames are added after the other images, and the prompt is returned
+// with their timestamps described before it. The images are buffered, so
+// the messages can be sent more than once.
+func splitPromptImages(prompt string, options []ChatOption) (string, []PromptImage, []ChatOption) {
 	var (
-		temperature   float64
-		seed          = c.SeedOrNegative
-		contextLength = c.ContextLength
-		promptImages  = []PromptImage{}
-		tools         = []Tool{}
-		format        = StructuredFormat{}
+		images = []PromptImage{}
+		frames = []Frames{}
+		rest   = make([]ChatOption, 0, len(options))
 	)
 
 	for _, option := range options {
 		switch opt := option.(type) {
 		case PromptImage:
-			promptImages = append(promptImages, opt)
+			images = append(images, opt)
 		case []PromptImage:
-			promptImages = opt
+			images = opt
+		case Frames:
+			frames = append(frames, opt)
+		default:
+			rest = append(rest, option)
+		}
+	}
+
+	descriptions := []string{}
+	for _, f := range frames {
+		if len(f) == 0 {
+			continue
+		}
+		descriptions = append(descriptions, f.describe(len(images)+1))
+		images = append(images, f.promptImages()...)
+	}
+	if len(descriptions) > 0 {
+		prompt = strings.Join(append(descriptions, prompt), "\n\n")
+	}
+
+	return prompt, bufferImages(images), rest
+}
+
+// chat sends the given messages to /api/chat and waits for the answer.
+func (c *Gollama) chat(ctx context.Context, messages []Message, options []ChatOption) (*ChatOuput, error) {
+	req, err := c.newChatRequest(messages, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp chatResponse
+	err = c.apiPost(ctx, "/api/chat", &resp, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Model != c.ModelName {
+		return nil, fmt.Errorf("model not found")
+	}
+
+	return c.newChatOutput(req, resp), nil
+}
+
+// newChatRequest builds the request body sent to /api/chat for the given
+// messages and options. It is shared by Chat, ChatStream and Conversation.
+func (c *Gollama) newChatRequest(messages []Message, options []ChatOption) (chatRequest, error) {
+	var (
+		tools       = []Tool{}
+		format      json.RawMessage
+		callOptions = Options{}
+		think       *bool
+		keepAlive   *time.Duration
+		logprobs    *Logprobs
+	)
+
+	for _, option := range options {
+		if f, ok, err := requestFormat(option); ok {
+			if err != nil {
+				return chatRequest{}, err
+			}
+			format = f
+			continue
+		}
+
+		switch opt := option.(type) {
 		case Tool:
 			tools = append(tools, opt)
 		case []Tool:
@@ -42,94 +144,177 @@ func (c *Gollama) Chat(ctx context.Context, prompt string, options ...ChatOption
 		case ToolSource:
 			t, err := opt.ListTools()
 			if err != nil {
-				return nil, err
+				return chatRequest{}, err
 			}
 			tools = append(tools, t...)
-		case StructuredFormat:
-			format = opt
+		case Options:
+			callOptions = callOptions.Merge(opt)
+		case Think:
+			think = Ptr(bool(opt))
+		case KeepAlive:
+			keepAlive = Ptr(time.Duration(opt))
+		case Logprobs:
+			logprobs = &opt
 		default:
 			continue
 		}
 	}
 
-	if seed < 0 {
-		temperature = c.TemperatureIfNegativeSeed
+	preprocess := c.imagePreprocessFor(options)
+	reqMessages := make([]chatMessage, 0, len(messages))
+	for _, message := range messages {
+		m, err := message.toChatMessage(preprocess)
+		if err != nil {
+			return chatRequest{}, err
+		}
+		reqMessages = append(reqMessages, m)
 	}
 
-	messages := []chatMessage{}
-	if c.SystemPrompt != "" {
-		messages = append(messages, chatMessage{
-			Role:    "system",
-			Content: c.SystemPrompt,
-		})
+	req := chatRequest{
+		Stream:    false,
+		Model:     c.ModelName,
+		Messages:  reqMessages,
+		Think:     think,
+		KeepAlive: c.keepAliveRequest(keepAlive),
+		Options:   c.newOptionsRequest(callOptions),
 	}
 
-	userMessage := chatMessage{
-		Role:    "user",
-		Content: prompt,
+	if logprobs != nil {
+		req.Logprobs = true
+		req.TopLogprobs = logprobs.TopN
 	}
 
-	base64VisionImages := make([]string, 0)
-	for _, image := range promptImages {
-		base64image, err := base64EncodeFile(image.Filename)
-		if err != nil {
-			return nil, err
-		}
-		base64VisionImages = append(base64VisionImages, base64image)
+	if len(tools) > 0 {
+		req.Tools = &tools
 	}
 
-	if len(base64VisionImages) > 0 {
-		userMessage.Images = base64VisionImages
-	}
+	req.Format = format
 
-	messages = append(messages, userMessage)
+	return req, nil
+}
 
-	req := chatRequest{
-		Stream:   false,
-		Model:    c.ModelName,
-		Messages: messages,
-		Options: chatOptionsRequest{
-			Seed:          seed,
-			Temperature:   temperature,
-			TopK:          c.TopK,
-			TopP:          c.TopP,
-			ContextLength: contextLength,
-		},
-	}
+// newChatOutput converts a final /api/chat response into a ChatOuput.
+func (c *Gollama) newChatOutput(req chatRequest, resp chatResponse) *ChatOuput {
+	thinking, content := splitThinking(resp.Message.Content, req.Think != nil && *req.Think)
 
-	if len(tools) > 0 {
-		req.Tools = &tools
+	out := &ChatOuput{
+		Role:               resp.Message.Role,
+		Content:            content,
+		Thinking:           strings.TrimSpace(resp.Message.Thinking + thinking),
+		ToolCalls:          resp.Message.ToolCalls,
+		PromptTokens:       resp.PromptEvalCount,
+		ResponseTokens:     resp.EvalCount,
+		Logprobs:           resp.Logprobs,
+		Model:              resp.Model,
+		CreatedAt:          parseTime(resp.CreatedAt),
+		DoneReason:         resp.DoneReason,
+		TotalDuration:      time.Duration(resp.TotalDuration),
+		LoadDuration:       time.Duration(resp.LoadDuration),
+		PromptEvalDuration: time.Duration(resp.PromptEvalDuration),
+		EvalDuration:       time.Duration(resp.EvalDuration),
 	}
 
-	if len(format.Properties) > 0 {
-		req.Format = &format
+	if c.TrimSpace {
+		out.Content = strings.TrimSpace(out.Content)
 	}
 
-	if c.ContextLength != 0 {
-		req.Options.ContextLength = c.ContextLength
+	return out
+}
+
+// ChatStream generates a response to a prompt like Chat, but streams it.
+//
+// The function fn is called for every chunk received from the server, in
+// order, with the content generated since the previous chunk and any tool
+// calls the model made. Returning an error from fn stops the stream and
+// ChatStream returns that error.
+//
+// Cancelling ctx closes the connection, which makes the server stop
+// generating; ChatStream then returns the context error.
+//
+// Once the stream is done, ChatStream returns a ChatOuput with the whole
+// content, all the tool calls and the token counts, just like Chat.
+func (c *Gollama) ChatStream(ctx context.Context, prompt string, fn func(ChatChunk) error, options ...ChatOption) (*ChatOuput, error) {
+	messages, options := c.promptMessages(prompt, options)
+	return c.chatStream(ctx, messages, fn, options)
+}
+
+// chatStream sends the given messages to /api/chat in streaming mode.
+func (c *Gollama) chatStream(ctx context.Context, messages []Message, fn func(ChatChunk) error, options []ChatOption) (*ChatOuput, error) {
+	req, err := c.newChatRequest(messages, options)
+	if err != nil {
+		return nil, err
 	}
+	req.Stream = true
 
-	var resp chatResponse
-	err := c.apiPost(ctx, "/api/chat", &resp, req)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		final     chatResponse
+		content   strings.Builder
+		thinking  strings.Builder
+		toolCalls []ToolCall
+		logprobs  []TokenLogprob
+		splitter  thinkSplitter
+	)
+
+	err = c.apiPostStream(ctx, "/api/chat", req, func(line []byte) error {
+		var resp chatResponse
+		if err := json.Unmarshal(line, &resp); err != nil {
+			return fmt.Errorf("error decoding stream chunk: %w", err)
+		}
+
+		if resp.Error != "" {
+			return fmt.Errorf("ollama: %s", resp.Error)
+		}
+
+		chunk := ChatChunk{
+			Thinking:  resp.Message.Thinking,
+			ToolCalls: resp.Message.ToolCalls,
+			Logprobs:  resp.Logprobs,
+			Done:      resp.Done,
+		}
+
+		inlineThinking, delta := splitter.split(resp.Message.Content)
+		if resp.Done {
+			t, c := splitter.flush()
+			inlineThinking += t
+			delta += c
+			final = resp
+		}
+		chunk.Thinking += inlineThinking
+		chunk.Content = delta
+
+		content.WriteString(chunk.Content)
+		thinking.WriteString(chunk.Thinking)
+		toolCalls = append(toolCalls, chunk.ToolCalls...)
+		logprobs = append(logprobs, chunk.Logprobs...)
+
+		if fn == nil {
+			return nil
+		}
+
+		return fn(chunk)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.Model != c.ModelName {
-		return nil, fmt.Errorf("model not found")
+	if !final.Done {
+		return nil, fmt.Errorf("stream ended before the response was done")
 	}
 
-	out := &ChatOuput{
-		Role:           resp.Message.Role,
-		Content:        resp.Message.Content,
-		ToolCalls:      resp.Message.ToolCalls,
-		PromptTokens:   resp.PromptEvalCount,
-		ResponseTokens: resp.EvalCount,
+	if final.Model != c.ModelName {
+		return nil, fmt.Errorf("model not found")
 	}
 
-	if c.TrimSpace {
-		out.Content = strings.TrimSpace(out.Content)
+	final.Message.Content = content.String()
+	final.Message.Thinking = thinking.String()
+	final.Message.ToolCalls = toolCalls
+	final.Logprobs = logprobs
+	if final.Message.Role == "" {
+		final.Message.Role = "assistant"
 	}
 
-	return out, nil
+	return c.newChatOutput(req, final), nil
 }
@@ -0,0 +1,78 @@
+package gollama
+
+import (
+	"context"
+	"fmt"
+)
+
+const defaultMaxAttempts = 3
+
+// MaxAttempts sets how many times ChatInto asks the model for a valid
+// answer (default 3).
+type MaxAttempts int
+
+// ChatInto asks the model to answer the prompt with a T, and decodes the
+// answer into it. T must be a struct: its schema is generated with
+// NewStructuredFormat and sent as the format of the request.
+//
+// The answer is checked against the schema like StructuredFormat.Validate
+// does, with safe coercions such as "3" to a number. When it is invalid or
+// can not be decoded, the model is asked again with the errors, up to
+// MaxAttempts times in total. ChatInto returns the decoded value and the
+// outputs of every attempt, including the failed ones.
+//
+// Options are the same as for Chat. Format options are ignored.
+func ChatInto[T any](ctx context.Context, c *Gollama, prompt string, options ...ChatOption) (T, []*ChatOuput, error) {
+	var zero T
+
+	format, err := NewStructuredFormat(zero)
+	if err != nil {
+		return zero, nil, err
+	}
+
+	maxAttempts := defaultMaxAttempts
+	chatOptions := make([]ChatOption, 0, len(options)+1)
+	for _, option := range options {
+		if _, ok, _ := requestFormat(option); ok {
+			continue
+		}
+		if opt, ok := option.(MaxAttempts); ok {
+			maxAttempts = max(1, int(opt))
+			continue
+		}
+		chatOptions = append(chatOptions, option)
+	}
+	chatOptions = append(chatOptions, format)
+
+	messages, chatOptions := c.promptMessages(prompt, chatOptions)
+
+	var (
+		outputs []*ChatOuput
+		lastErr error
+	)
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		out, err := c.chat(ctx, messages, chatOptions)
+		if err != nil {
+			return zero, outputs, err
+		}
+		outputs = append(outputs, out)
+
+		var value T
+		if lastErr = out.decodeValidated(format, &value); lastErr == nil {
+			return value, outputs, nil
+		}
+
+		messages = append(messages,
+			Message{Role: RoleAssistant, Content: out.Content},
+			Message{Role: RoleUser, Content: retryPrompt(lastErr)},
+		)
+	}
+
+	return zero, outputs, fmt.Errorf("no valid answer after %d attempts: %w", maxAttempts, lastErr)
+}
+
+// retryPrompt asks the model to fix an answer that could not be used.
+func retryPrompt(err error) string {
+	return fmt.Sprintf("Your answer could not be used: %s. Answer again with only the corrected JSON, following the requested schema.", err)
+}
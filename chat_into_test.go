@@ -0,0 +1,163 @@
+package gollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestChatInto(t *testing.T) {
+	type capital struct {
+		Country string `json:"country" required:"true"`
+		City    string `json:"city" required:"true"`
+	}
+
+	tests := []struct {
+		name         string
+		replies      []string
+		options      []ChatOption
+		want         capital
+		wantOutputs  int
+		wantErr      bool
+		wantRetryMsg string
+	}{
+		{
+			name:        "Valid first answer",
+			replies:     []string{`{"country":"France","city":"Paris"}`},
+			want:        capital{Country: "France", City: "Paris"},
+			wantOutputs: 1,
+		},
+		{
+			name:         "Retry after malformed JSON",
+			replies:      []string{`{"country":"France","city":}`, `{"country":"France","city":"Paris"}`},
+			want:         capital{Country: "France", City: "Paris"},
+			wantOutputs:  2,
+			wantRetryMsg: "Your answer could not be used: ",
+		},
+		{
+			name:         "Retry after invalid answer",
+			replies:      []string{`{"country":"France"}`, `{"country":"France","city":"Paris"}`},
+			want:         capital{Country: "France", City: "Paris"},
+			wantOutputs:  2,
+			wantRetryMsg: "Your answer could not be used: city: missing required field.",
+		},
+		{
+			name:        "Coerced answer",
+			replies:     []string{`{"country":"France","city":75}`},
+			want:        capital{Country: "France", City: "75"},
+			wantOutputs: 1,
+		},
+		{
+			name:        "Give up after MaxAttempts",
+			replies:     []string{`nope`, `still nope`, `never`},
+			options:     []ChatOption{MaxAttempts(2)},
+			wantOutputs: 2,
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []chatRequest
+			c := newTestServer(t, "test", func(w http.ResponseWriter, r *http.Request) {
+				var req chatRequest
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Fatal(err)
+				}
+				requests = append(requests, req)
+				json.NewEncoder(w).Encode(chatResponse{
+					Model:   "test",
+					Message: messageResponse{Role: "assistant", Content: tt.replies[len(requests)-1]},
+					Done:    true,
+				})
+			})
+
+			got, outputs, err := ChatInto[capital](context.Background(), c, "Capital of France?", tt.options...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ChatInto() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ChatInto() = %+v, want %+v", got, tt.want)
+			}
+			if len(outputs) != tt.wantOutputs {
+				t.Errorf("len(outputs) = %d, want %d", len(outputs), tt.wantOutputs)
+			}
+
+			var sent StructuredFormat
+			if err := json.Unmarshal(requests[0].Format, &sent); err != nil || len(sent.Properties) != 2 {
+				t.Errorf("the schema of the type was not sent: %s", requests[0].Format)
+			}
+
+			if tt.wantRetryMsg != "" {
+				last := requests[1].Messages[len(requests[1].Messages)-1]
+				if last.Role != RoleUser || !strings.HasPrefix(last.Content, tt.wantRetryMsg) {
+					t.Errorf("retry message = %+v", last)
+				}
+			}
+		})
+	}
+}
+
+func TestChatInto_RetryWithReaderImage(t *testing.T) {
+	type answer struct {
+		Color string `json:"color" required:"true"`
+	}
+
+	road, err := os.ReadFile("./test/road.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replies := []string{`{}`, `{"color":"gray"}`}
+	var images [][]string
+	c := newTestServer(t, "test", func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		images = append(images, req.Messages[0].Images)
+		json.NewEncoder(w).Encode(chatResponse{
+			Model:   "test",
+			Message: messageResponse{Role: "assistant", Content: replies[len(images)-1]},
+			Done:    true,
+		})
+	})
+
+	got, _, err := ChatInto[answer](context.Background(), c, "Color?", ImageFromReader(bytes.NewReader(road)))
+	if err != nil || got.Color != "gray" {
+		t.Fatalf("ChatInto() = %+v, %v", got, err)
+	}
+	if len(images) != 2 || len(images[1]) != 1 || images[1][0] != images[0][0] {
+		t.Errorf("the image was not sent again on the retry")
+	}
+}
+
+func TestChatInto_LargeIntegers(t *testing.T) {
+	type record struct {
+		ID    int64  `json:"id" required:"true"`
+		Ref   int64  `json:"ref" required:"true"`
+		Label string `json:"label" required:"true"`
+	}
+
+	c := newTestServer(t, "test", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(chatResponse{
+			Model:   "test",
+			Message: messageResponse{Role: "assistant", Content: `{"id":9007199254740993,"ref":"9007199254740995","label":9007199254740997}`},
+			Done:    true,
+		})
+	})
+
+	got, _, err := ChatInto[record](context.Background(), c, "Record?")
+	if err != nil {
+		t.Fatalf("ChatInto() error = %v", err)
+	}
+
+	want := record{ID: 9007199254740993, Ref: 9007199254740995, Label: "9007199254740997"}
+	if got != want {
+		t.Errorf("ChatInto() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,187 @@
+package gollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// ChatStreamInto streams the answer to the prompt like ChatStream, asking
+// for a T like ChatInto does, and decodes the answer while it is generated.
+//
+// The function fn is called with a snapshot of T each time a value of the
+// answer may be complete, and the snapshot changed: the JSON received so
+// far is completed by closing its open strings, arrays and objects, and
+// members whose value is not there yet are left out. Returning an error
+// from fn stops the stream.
+//
+// Once the stream is done, the whole answer is checked against the schema
+// and decoded like ChatInto, without retries, and returned with the output.
+func ChatStreamInto[T any](ctx context.Context, c *Gollama, prompt string, fn func(T) error, options ...ChatOption) (T, *ChatOuput, error) {
+	var zero T
+
+	format, err := NewStructuredFormat(zero)
+	if err != nil {
+		return zero, nil, err
+	}
+
+	var last []byte
+	out, err := streamStructured(ctx, c, prompt, format, options, 0, func(r *jsonRepairer) error {
+		if fn == nil {
+			return nil
+		}
+
+		snapshot, ok := r.snapshot()
+		if !ok || bytes.Equal(snapshot.out, last) {
+			return nil
+		}
+
+		var partial T
+		if err := json.Unmarshal(snapshot.out, &partial); err != nil {
+			return nil
+		}
+		last = snapshot.out
+
+		return fn(partial)
+	})
+	if err != nil {
+		return zero, out, err
+	}
+
+	var value T
+	if err := out.decodeValidated(format, &value); err != nil {
+		return zero, out, err
+	}
+
+	return value, out, nil
+}
+
+// streamedItems is the answer asked by ChatStreamItems.
+type streamedItems[T any] struct {
+	Items []T `json:"items" required:"true"`
+}
+
+// ChatStreamItems streams the answer to the prompt like ChatStream, asking
+// for a list of T, and calls fn with each element of the list as soon as
+// it is complete, in order.
+//
+// The model is asked for an object with an "items" array, generated from
+// T like ChatInto does. Once the stream is done, the whole answer is
+// checked against the schema, fn is called for the elements not delivered
+// yet, and all of them are returned with the output.
+func ChatStreamItems[T any](ctx context.Context, c *Gollama, prompt string, fn func(T) error, options ...ChatOption) ([]T, *ChatOuput, error) {
+	format, err := NewStructuredFormat(streamedItems[T]{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sent := 0
+	deliver := func(items []T) error {
+		for ; sent < len(items); sent++ {
+			if fn == nil {
+				continue
+			}
+			if err := fn(items[sent]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// The elements of the items array are read one by one, as they end.
+	out, err := streamStructured(ctx, c, prompt, format, options, 2, func(r *jsonRepairer) error {
+		if fn == nil {
+			return nil
+		}
+
+		for ; sent < len(r.elements); sent++ {
+			var item T
+			span := r.elements[sent]
+			if err := json.Unmarshal(r.out[span.start:span.end], &item); err != nil {
+				// It is decoded with the whole answer, once validated.
+				return nil
+			}
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, out, err
+	}
+
+	var value streamedItems[T]
+	if err := out.decodeValidated(format, &value); err != nil {
+		return nil, out, err
+	}
+
+	if err := deliver(value.Items); err != nil {
+		return nil, out, err
+	}
+
+	return value.Items, out, nil
+}
+
+// streamStructured streams the answer to the prompt with the format, and
+// reads the JSON object of the answer as it arrives. fn is called with the
+// JSON received so far after the chunks that may complete a value. When
+// elementDepth is set, the elements of the arrays at that depth are
+// recorded, see jsonRepairer.
+//
+// If the first brace turns out to be prose, the object is looked for again
+// from the next one.
+func streamStructured(ctx context.Context, c *Gollama, prompt string, format StructuredFormat, options []ChatOption, elementDepth int, fn func(*jsonRepairer) error) (*ChatOuput, error) {
+	chatOptions := make([]ChatOption, 0, len(options)+1)
+	for _, option := range options {
+		if _, ok, _ := requestFormat(option); ok {
+			continue
+		}
+		chatOptions = append(chatOptions, option)
+	}
+	chatOptions = append(chatOptions, format)
+
+	messages, chatOptions := c.promptMessages(prompt, chatOptions)
+
+	var (
+		r *jsonRepairer
+		// read is the content from the start of the object, to read it
+		// again from the next brace if it is not JSON.
+		read []byte
+	)
+	return c.chatStream(ctx, messages, func(chunk ChatChunk) error {
+		if chunk.Content == "" {
+			return nil
+		}
+
+		text := chunk.Content
+		if r == nil {
+			start := strings.IndexByte(text, '{')
+			if start < 0 {
+				return nil
+			}
+			r = &jsonRepairer{elementDepth: elementDepth}
+			text = text[start:]
+		}
+		read = append(read, text...)
+
+		for !r.feed(text) {
+			next := bytes.IndexByte(read[1:], '{')
+			if next < 0 {
+				r, read = nil, nil
+				return nil
+			}
+			read = read[next+1:]
+			r = &jsonRepairer{elementDepth: elementDepth}
+			text = string(read)
+		}
+
+		// A value can only be complete after one of these.
+		if !strings.ContainsAny(chunk.Content, "}],") {
+			return nil
+		}
+
+		return fn(r)
+	}, chatOptions)
+}
@@ -0,0 +1,158 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// newStreamServer returns a client whose server streams the content in the
+// given pieces.
+func newStreamServer(t *testing.T, pieces []string) *Gollama {
+	return newTestServer(t, "test", func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !req.Stream || req.Format == nil {
+			t.Errorf("expected a streaming request with a format, got %+v (%v)", req, err)
+		}
+		for _, piece := range pieces {
+			json.NewEncoder(w).Encode(chatResponse{Model: "test", Message: messageResponse{Role: "assistant", Content: piece}})
+		}
+		json.NewEncoder(w).Encode(chatResponse{Model: "test", Message: messageResponse{Role: "assistant"}, Done: true})
+	})
+}
+
+func TestChatStreamInto(t *testing.T) {
+	type person struct {
+		Name string   `json:"name" required:"true"`
+		Tags []string `json:"tags" required:"true"`
+	}
+
+	c := newStreamServer(t, []string{`{"na`, `me": "Ad`, `a", "tags": ["math`, `", "code"`, `]}`})
+
+	var snapshots []string
+	got, out, err := ChatStreamInto(context.Background(), c, "Who?", func(p person) error {
+		snapshots = append(snapshots, fmt.Sprintf("%s%v", p.Name, p.Tags))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ChatStreamInto() error = %v", err)
+	}
+
+	// Snapshots are taken after the chunks that may complete a value.
+	want := []string{"Ada[math]", "Ada[math code]"}
+	if !reflect.DeepEqual(snapshots, want) {
+		t.Errorf("snapshots = %q, want %q", snapshots, want)
+	}
+	if !reflect.DeepEqual(got, person{Name: "Ada", Tags: []string{"math", "code"}}) || out.Content == "" {
+		t.Errorf("ChatStreamInto() = %+v, %+v", got, out)
+	}
+}
+
+func TestChatStreamItems_LargeIntegers(t *testing.T) {
+	type record struct {
+		ID int64 `json:"id" required:"true"`
+	}
+
+	c := newStreamServer(t, []string{`{"items": [{"id": 9007199254740993}, {"id": "9007199254740995"}]}`})
+
+	got, _, err := ChatStreamItems[record](context.Background(), c, "Records?", nil)
+	if err != nil {
+		t.Fatalf("ChatStreamItems() error = %v", err)
+	}
+	if want := []record{{9007199254740993}, {9007199254740995}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ChatStreamItems() = %+v, want %+v", got, want)
+	}
+}
+
+func TestJSONRepairer_elements(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{prefix: `{"ite`, want: []string{}},
+		{prefix: `{"items": [`, want: []string{}},
+		{prefix: `{"items": ["Paris", "Ro`, want: []string{`"Paris"`}},
+		{prefix: `{"items": ["Paris", "Rome"`, want: []string{`"Paris"`, `"Rome"`}},
+		{prefix: `{"items": [1, 2`, want: []string{"1"}},
+		{prefix: `{"items": [{"a": [1]}, {"a": [2`, want: []string{`{"a":[1]}`}},
+		{prefix: `{"items": ["Paris", "Rome",`, want: []string{`"Paris"`, `"Rome"`}},
+		{prefix: `{"items": ["Paris", "Rome"]`, want: []string{`"Paris"`, `"Rome"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			r := jsonRepairer{elementDepth: 2}
+			if !r.feed(tt.prefix) {
+				t.Fatalf("feed(%q) failed", tt.prefix)
+			}
+			got := []string{}
+			for _, span := range r.elements {
+				got = append(got, string(r.out[span.start:span.end]))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("elements = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChatStreamItems(t *testing.T) {
+	type city struct {
+		Name       string `json:"name" required:"true"`
+		Population int    `json:"population" required:"true"`
+	}
+
+	tests := []struct {
+		name          string
+		pieces        []string
+		wantDelivered []string
+		wantErr       bool
+	}{
+		{
+			name:          "Elements in order",
+			pieces:        []string{`{"items": [{"name": "Pa`, `ris", "population": 2}, {"name"`, `: "Rome", "popu`, `lation": 3}`, `]}`},
+			wantDelivered: []string{"Paris", "Rome"},
+		},
+		{
+			name:          "Coerced at the end",
+			pieces:        []string{`{"items": [{"name": "Paris", "population": "2"}]}`},
+			wantDelivered: []string{"Paris"},
+		},
+		{
+			name:          "Prose before the answer",
+			pieces:        []string{`Here {you} go: {"items": [{"name": "Paris", "population": 2},`, ` {"name": "Rome"}]}`},
+			wantDelivered: []string{"Paris", "Rome"},
+			wantErr:       true,
+		},
+		{
+			name:          "Invalid answer",
+			pieces:        []string{`{"items": [{"name": "Paris"}]}`},
+			wantDelivered: []string{"Paris"},
+			wantErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newStreamServer(t, tt.pieces)
+
+			var delivered []string
+			got, _, err := ChatStreamItems(context.Background(), c, "Cities?", func(item city) error {
+				delivered = append(delivered, item.Name)
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ChatStreamItems() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(delivered, tt.wantDelivered) {
+				t.Errorf("delivered = %q, want %q", delivered, tt.wantDelivered)
+			}
+			if !tt.wantErr && len(got) != len(delivered) {
+				t.Errorf("ChatStreamItems() = %+v, delivered %q", got, delivered)
+			}
+		})
+	}
+}
@@ -3,7 +3,13 @@ package gollama
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestGollama_Chat(t *testing.T) {
@@ -124,3 +130,118 @@ func TestGollama_Chat(t *testing.T) {
 		})
 	}
 }
+
+// newTestServer starts an HTTP server that answers every request with
+// handler, and returns a Gollama pointing at it.
+func newTestServer(t *testing.T, model string, handler http.HandlerFunc) *Gollama {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	c := New(model)
+	c.ServerAddr = srv.URL
+	c.ModelName = model
+	return c
+}
+
+func TestGollama_ChatStream(t *testing.T) {
+	tests := []struct {
+		name        string
+		lines       []string
+		wantChunks  []string
+		wantContent string
+		wantTokens  int
+		wantErr     bool
+	}{
+		{
+			name: "Content deltas",
+			lines: []string{
+				`{"model":"test","message":{"role":"assistant","content":"Hello"},"done":false}`,
+				`{"model":"test","message":{"role":"assistant","content":" world"},"done":false}`,
+				`{"model":"test","message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","prompt_eval_count":3,"eval_count":2}`,
+			},
+			wantChunks:  []string{"Hello", " world", ""},
+			wantContent: "Hello world",
+			wantTokens:  2,
+		},
+		{
+			name: "Error in stream",
+			lines: []string{
+				`{"model":"test","message":{"role":"assistant","content":"Hel"},"done":false}`,
+				`{"error":"out of memory"}`,
+			},
+			wantChunks: []string{"Hel"},
+			wantErr:    true,
+		},
+		{
+			name: "Stream not done",
+			lines: []string{
+				`{"model":"test","message":{"role":"assistant","content":"Hel"},"done":false}`,
+			},
+			wantChunks: []string{"Hel"},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestServer(t, "test", func(w http.ResponseWriter, r *http.Request) {
+				var req chatRequest
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !req.Stream {
+					t.Errorf("expected a streaming request, got %+v (%v)", req, err)
+				}
+				for _, line := range tt.lines {
+					fmt.Fprintln(w, line)
+				}
+			})
+
+			var chunks []string
+			got, err := c.ChatStream(context.Background(), "hi", func(chunk ChatChunk) error {
+				chunks = append(chunks, chunk.Content)
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Gollama.ChatStream() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(chunks, tt.wantChunks) {
+				t.Errorf("Gollama.ChatStream() chunks = %q, want %q", chunks, tt.wantChunks)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Content != tt.wantContent || got.ResponseTokens != tt.wantTokens {
+				t.Errorf("Gollama.ChatStream() = %+v, want content %q and %d tokens", got, tt.wantContent, tt.wantTokens)
+			}
+		})
+	}
+}
+
+func TestGollama_ChatStream_Cancel(t *testing.T) {
+	c := newTestServer(t, "test", func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 100; i++ {
+			select {
+			case <-r.Context().Done():
+				return
+			default:
+			}
+			fmt.Fprintln(w, `{"model":"test","message":{"role":"assistant","content":"x"},"done":false}`)
+			w.(http.Flusher).Flush()
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	n := 0
+	_, err := c.ChatStream(ctx, "hi", func(chunk ChatChunk) error {
+		n++
+		if n == 2 {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Gollama.ChatStream() error = %v, want context.Canceled", err)
+	}
+}
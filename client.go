@@ -104,5 +104,9 @@ func NewWithConfig(config Gollama) *Gollama {
 		oc.SystemPrompt = config.SystemPrompt
 	}
 
+	oc.Options = config.Options
+	oc.KeepAlive = config.KeepAlive
+	oc.ImagePreprocess = config.ImagePreprocess
+
 	return &oc
 }
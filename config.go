@@ -18,6 +18,9 @@ type Gollama struct {
 	Verbose                   bool
 	ContextLength             int64
 	SystemPrompt              string
+	Options                   Options
+	KeepAlive                 *time.Duration
+	ImagePreprocess           *ImagePreprocess
 }
 
 const (
@@ -45,8 +48,11 @@ func (c *Gollama) SetRandomSeed() *Gollama {
 }
 
 // SetTemperature The temperature of the model. Increasing the temperature will make the model answer more creatively. (Default: 0.8)
+//
+// The temperature is used even if a fixed seed is set.
 func (c *Gollama) SetTemperature(temperature float64) *Gollama {
 	c.TemperatureIfNegativeSeed = temperature
+	c.Options.Temperature = Ptr(temperature)
 	return c
 }
 
@@ -68,6 +74,12 @@ func (c *Gollama) SetContextLength(contextLength int64) *Gollama {
 	return c
 }
 
+// SetKeepAlive Sets how long the model stays loaded in memory after a request. A negative duration keeps it loaded forever, and 0 unloads it right after the request. (Default: 5m)
+func (c *Gollama) SetKeepAlive(keepAlive time.Duration) *Gollama {
+	c.KeepAlive = &keepAlive
+	return c
+}
+
 func (c *Gollama) SetSystemPrompt(prompt string) *Gollama {
 	c.SystemPrompt = prompt
 	return c
@@ -79,3 +91,16 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// keepAliveRequest returns the keep_alive value sent to Ollama: the per-call
+// value if any, else the Gollama setting, else empty to use the server
+// default.
+func (c *Gollama) keepAliveRequest(call *time.Duration) string {
+	if call == nil {
+		call = c.KeepAlive
+	}
+	if call == nil {
+		return ""
+	}
+	return call.String()
+}
@@ -0,0 +1,291 @@
+package gollama
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	defaultContextLength    = 2048 // Ollama's default num_ctx
+	defaultContextThreshold = 0.9  // leave some room for the answer
+	estimatedImageTokens    = 768  // rough cost of an image for vision models
+	estimatedMessageTokens  = 4    // role and template tokens around every message
+	summaryPrefix           = "Summary of the earlier conversation:\n"
+)
+
+// ContextWindow configures how a Conversation keeps its history within the
+// model context window.
+//
+// Before every turn the size of the prompt is estimated. When it goes over
+// Threshold times the context length, Strategy is used to shrink the history.
+// Once the turn succeeds and the shrunk history is kept, OnEvict, if set, is
+// called with what was removed.
+type ContextWindow struct {
+	Strategy  ContextStrategy
+	Threshold float64        // fraction of the context length to fill before evicting (default 0.9)
+	MaxTokens int            // context length to use instead of the num_ctx option
+	OnEvict   func(Eviction) // called every time messages are evicted
+}
+
+// Eviction reports the messages a ContextStrategy removed from a history.
+type Eviction struct {
+	Evicted      []Message `json:"evicted"`
+	Summary      string    `json:"summary,omitempty"`
+	TokensBefore int       `json:"tokens_before"`
+	TokensAfter  int       `json:"tokens_after"`
+}
+
+// ContextStrategy shrinks a history so its estimated size fits in budget
+// tokens. System messages at the start of the history and the last turn
+// must always be kept.
+type ContextStrategy interface {
+	Fit(ctx context.Context, messages []Message, budget int) ([]Message, Eviction, error)
+}
+
+// SetContextWindow enables automatic context-window management for the
+// conversation.
+func (cv *Conversation) SetContextWindow(window ContextWindow) {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+
+	cv.window = &window
+}
+
+// fitContext applies the context window, if any, to the messages about to
+// be sent with the options. If messages were evicted, it also returns a
+// function reporting them to OnEvict, to call once the fitted messages are
+// kept.
+func (cv *Conversation) fitContext(ctx context.Context, messages []Message, options []ChatOption) ([]Message, func(), error) {
+	cv.mu.Lock()
+	window := cv.window
+	cv.mu.Unlock()
+
+	if window == nil || window.Strategy == nil {
+		return messages, nil, nil
+	}
+
+	// The limit is the num_ctx sent to the server, unless set.
+	limit := window.MaxTokens
+	if limit == 0 {
+		callOptions := Options{}
+		for _, option := range options {
+			if opt, ok := option.(Options); ok {
+				callOptions = callOptions.Merge(opt)
+			}
+		}
+		if numCtx := cv.client.newOptionsRequest(callOptions).NumCtx; numCtx != nil {
+			limit = int(*numCtx)
+		}
+	}
+	if limit == 0 {
+		limit = defaultContextLength
+	}
+
+	threshold := window.Threshold
+	if threshold <= 0 || threshold > 1 {
+		threshold = defaultContextThreshold
+	}
+
+	budget := int(float64(limit) * threshold)
+	if EstimateTokens(messages) <= budget {
+		return messages, nil, nil
+	}
+
+	fitted, eviction, err := window.Strategy.Fit(ctx, messages, budget)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(eviction.Evicted) == 0 || window.OnEvict == nil {
+		return fitted, nil, nil
+	}
+
+	return fitted, func() { window.OnEvict(eviction) }, nil
+}
+
+// EstimateTokens returns a rough estimate of the number of tokens the
+// messages take in the prompt, counting about four characters per token.
+func EstimateTokens(messages []Message) int {
+	tokens := 0
+	for _, m := range messages {
+		tokens += estimatedMessageTokens
+		tokens += (len(m.Content) + 3) / 4
+		tokens += len(m.Images) * estimatedImageTokens
+		for _, call := range m.ToolCalls {
+			tokens += (len(call.Function.Name) + len(fmt.Sprint(call.Function.Arguments)) + 3) / 4
+		}
+	}
+	return tokens
+}
+
+// DropOldest evicts the oldest turns, one at a time, until the history fits.
+// The leading system messages are always kept.
+type DropOldest struct{}
+
+// Fit implements ContextStrategy.
+func (DropOldest) Fit(ctx context.Context, messages []Message, budget int) ([]Message, Eviction, error) {
+	system, turns := splitTurns(messages)
+	eviction := Eviction{TokensBefore: EstimateTokens(messages)}
+
+	for len(turns) > 1 && EstimateTokens(joinTurns(system, turns)) > budget {
+		eviction.Evicted = append(eviction.Evicted, turns[0]...)
+		turns = turns[1:]
+	}
+
+	fitted := joinTurns(system, turns)
+	eviction.TokensAfter = EstimateTokens(fitted)
+	return fitted, eviction, nil
+}
+
+// SlidingWindow keeps only the last Turns turns, plus the leading system
+// messages. If that is still too big, older turns are dropped as in
+// DropOldest.
+type SlidingWindow struct {
+	Turns int
+}
+
+// Fit implements ContextStrategy.
+func (s SlidingWindow) Fit(ctx context.Context, messages []Message, budget int) ([]Message, Eviction, error) {
+	system, turns := splitTurns(messages)
+	eviction := Eviction{TokensBefore: EstimateTokens(messages)}
+
+	keep := s.Turns
+	if keep < 1 {
+		keep = 1
+	}
+	for len(turns) > keep {
+		eviction.Evicted = append(eviction.Evicted, turns[0]...)
+		turns = turns[1:]
+	}
+
+	fitted, more, err := DropOldest{}.Fit(ctx, joinTurns(system, turns), budget)
+	if err != nil {
+		return nil, Eviction{}, err
+	}
+
+	eviction.Evicted = append(eviction.Evicted, more.Evicted...)
+	eviction.TokensAfter = more.TokensAfter
+	return fitted, eviction, nil
+}
+
+// RollingSummary replaces the oldest turns with a summary written by the
+// Summarizer, which may use a different (e.g. smaller) model than the
+// conversation. The summary is kept as a system message after the leading
+// system messages, and is folded into the next summary when more turns are
+// evicted.
+//
+// KeepTurns is the number of recent turns that are never summarized
+// (default 1).
+type RollingSummary struct {
+	Summarizer *Gollama
+	KeepTurns  int
+}
+
+// Fit implements ContextStrategy.
+func (s RollingSummary) Fit(ctx context.Context, messages []Message, budget int) ([]Message, Eviction, error) {
+	if s.Summarizer == nil {
+		return nil, Eviction{}, fmt.Errorf("rolling summary needs a summarizer")
+	}
+
+	system, turns := splitTurns(messages)
+	eviction := Eviction{TokensBefore: EstimateTokens(messages)}
+
+	// A previous summary is folded into the new one.
+	var previous string
+	if n := len(system); n > 0 && strings.HasPrefix(system[n-1].Content, summaryPrefix) {
+		previous = strings.TrimPrefix(system[n-1].Content, summaryPrefix)
+		system = system[: n-1 : n-1]
+	}
+
+	keep := s.KeepTurns
+	if keep < 1 {
+		keep = 1
+	}
+
+	// Evict the oldest turns until the rest, plus a summary about the size
+	// of the previous one, fits.
+	reserve := EstimateTokens([]Message{{Content: summaryPrefix + previous}})
+	for len(turns) > keep && EstimateTokens(joinTurns(system, turns))+reserve > budget {
+		eviction.Evicted = append(eviction.Evicted, turns[0]...)
+		turns = turns[1:]
+	}
+
+	if len(eviction.Evicted) == 0 {
+		eviction.TokensAfter = eviction.TokensBefore
+		return messages, eviction, nil
+	}
+
+	summary, err := s.summarize(ctx, previous, eviction.Evicted)
+	if err != nil {
+		return nil, Eviction{}, err
+	}
+	eviction.Summary = summary
+
+	system = append(system, Message{
+		Role:    RoleSystem,
+		Content: summaryPrefix + summary,
+	})
+
+	fitted := joinTurns(system, turns)
+	eviction.TokensAfter = EstimateTokens(fitted)
+	return fitted, eviction, nil
+}
+
+func (s RollingSummary) summarize(ctx context.Context, previous string, evicted []Message) (string, error) {
+	var sb strings.Builder
+	sb.WriteString("Summarize the following conversation in a few sentences. ")
+	sb.WriteString("Keep names, facts, decisions and open questions. Answer only with the summary.\n\n")
+
+	if previous != "" {
+		sb.WriteString("Summary so far: ")
+		sb.WriteString(previous)
+		sb.WriteString("\n\n")
+	}
+
+	for _, m := range evicted {
+		if m.Content == "" {
+			continue
+		}
+		sb.WriteString(m.Role)
+		sb.WriteString(": ")
+		sb.WriteString(m.Content)
+		sb.WriteString("\n")
+	}
+
+	out, err := s.Summarizer.Chat(ctx, sb.String())
+	if err != nil {
+		return "", fmt.Errorf("error summarizing conversation: %w", err)
+	}
+
+	return strings.TrimSpace(out.Content), nil
+}
+
+// splitTurns splits a history into its leading system messages and turns.
+// A turn starts with a user message and holds everything up to the next
+// one, so tool calls are never separated from their results.
+func splitTurns(messages []Message) ([]Message, [][]Message) {
+	i := 0
+	for i < len(messages) && messages[i].Role == RoleSystem {
+		i++
+	}
+
+	system := messages[:i:i]
+	turns := [][]Message{}
+	for _, m := range messages[i:] {
+		if m.Role == RoleUser || len(turns) == 0 {
+			turns = append(turns, []Message{})
+		}
+		turns[len(turns)-1] = append(turns[len(turns)-1], m)
+	}
+
+	return system, turns
+}
+
+func joinTurns(system []Message, turns [][]Message) []Message {
+	messages := append([]Message{}, system...)
+	for _, turn := range turns {
+		messages = append(messages, turn...)
+	}
+	return messages
+}
@@ -0,0 +1,238 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func contentsOf(messages []Message) []string {
+	out := []string{}
+	for _, m := range messages {
+		out = append(out, m.Content)
+	}
+	return out
+}
+
+func TestContextStrategy_Fit(t *testing.T) {
+	long := strings.Repeat("x", 400) // ~100 tokens
+	history := []Message{
+		{Role: RoleSystem, Content: "sys"},
+		{Role: RoleUser, Content: "u1 " + long},
+		{Role: RoleAssistant, Content: "a1 " + long},
+		{Role: RoleUser, Content: "u2 " + long},
+		{Role: RoleAssistant, ToolCalls: []ToolCall{{Function: ToolCallFunction{Name: "f"}}}},
+		{Role: RoleTool, Content: "t2"},
+		{Role: RoleAssistant, Content: "a2"},
+		{Role: RoleUser, Content: "u3"},
+	}
+
+	tests := []struct {
+		name        string
+		strategy    ContextStrategy
+		budget      int
+		wantKept    []string
+		wantEvicted int
+	}{
+		{
+			name:        "DropOldest keeps system and drops whole turns",
+			strategy:    DropOldest{},
+			budget:      250,
+			wantKept:    []string{"sys", "u2 " + long, "", "t2", "a2", "u3"},
+			wantEvicted: 2,
+		},
+		{
+			name:        "DropOldest keeps the last turn",
+			strategy:    DropOldest{},
+			budget:      1,
+			wantKept:    []string{"sys", "u3"},
+			wantEvicted: 6,
+		},
+		{
+			name:        "SlidingWindow",
+			strategy:    SlidingWindow{Turns: 1},
+			budget:      10000,
+			wantKept:    []string{"sys", "u3"},
+			wantEvicted: 6,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, eviction, err := tt.strategy.Fit(context.Background(), history, tt.budget)
+			if err != nil {
+				t.Fatalf("Fit() error = %v", err)
+			}
+			if !reflect.DeepEqual(contentsOf(got), tt.wantKept) {
+				t.Errorf("Fit() kept %q, want %q", contentsOf(got), tt.wantKept)
+			}
+			if len(eviction.Evicted) != tt.wantEvicted {
+				t.Errorf("Fit() evicted %d messages, want %d", len(eviction.Evicted), tt.wantEvicted)
+			}
+			if eviction.TokensAfter != EstimateTokens(got) || eviction.TokensBefore != EstimateTokens(history) {
+				t.Errorf("Fit() eviction tokens = %d -> %d", eviction.TokensBefore, eviction.TokensAfter)
+			}
+		})
+	}
+}
+
+func TestRollingSummary_Fit(t *testing.T) {
+	summarizer := newTestServer(t, "small", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(chatResponse{
+			Model:   "small",
+			Message: messageResponse{Role: "assistant", Content: "The user is Ana."},
+			Done:    true,
+		})
+	})
+
+	long := strings.Repeat("x", 400)
+	history := []Message{
+		{Role: RoleSystem, Content: "sys"},
+		{Role: RoleUser, Content: "I am Ana " + long},
+		{Role: RoleAssistant, Content: "Hi " + long},
+		{Role: RoleUser, Content: "what is my name?"},
+	}
+
+	got, eviction, err := RollingSummary{Summarizer: summarizer}.Fit(context.Background(), history, 50)
+	if err != nil {
+		t.Fatalf("Fit() error = %v", err)
+	}
+
+	want := []string{"sys", summaryPrefix + "The user is Ana.", "what is my name?"}
+	if !reflect.DeepEqual(contentsOf(got), want) {
+		t.Errorf("Fit() = %q, want %q", contentsOf(got), want)
+	}
+	if eviction.Summary != "The user is Ana." || len(eviction.Evicted) != 2 {
+		t.Errorf("Fit() eviction = %+v", eviction)
+	}
+	if history[1].Content != "I am Ana "+long {
+		t.Errorf("Fit() modified its input")
+	}
+}
+
+func TestConversation_ContextWindow(t *testing.T) {
+	var sent []chatMessage
+	c := newTestServer(t, "test", func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		sent = req.Messages
+		json.NewEncoder(w).Encode(chatResponse{
+			Model:   "test",
+			Message: messageResponse{Role: "assistant", Content: "ok"},
+			Done:    true,
+		})
+	})
+
+	var evictions []Eviction
+	cv := c.NewConversation()
+	cv.SetContextWindow(ContextWindow{
+		Strategy:  DropOldest{},
+		MaxTokens: 150,
+		OnEvict:   func(e Eviction) { evictions = append(evictions, e) },
+	})
+
+	for i := 0; i < 3; i++ {
+		if _, err := cv.Send(context.Background(), strings.Repeat("y", 200)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Every turn takes ~59 tokens, so only two fit in the 135 token budget.
+	if len(evictions) != 1 || len(evictions[0].Evicted) != 2 {
+		t.Fatalf("evictions = %+v, want one eviction of the first turn", evictions)
+	}
+	if len(sent) != 3 {
+		t.Errorf("last request sent %d messages, want 3", len(sent))
+	}
+	if n := len(cv.Messages()); n != 4 {
+		t.Errorf("Conversation.Messages() has %d messages, want 4", n)
+	}
+}
+
+func TestConversation_ContextWindow_FailedTurn(t *testing.T) {
+	fail := true
+	c := newTestServer(t, "test", func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			http.Error(w, `{"error":"model is busy"}`, http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(chatResponse{
+			Model:   "test",
+			Message: messageResponse{Role: "assistant", Content: "ok"},
+			Done:    true,
+		})
+	})
+
+	var evictions []Eviction
+	cv := c.NewConversation()
+	cv.AddUser(strings.Repeat("x", 200))
+	cv.AddAssistant("ok")
+	cv.SetContextWindow(ContextWindow{
+		Strategy:  DropOldest{},
+		MaxTokens: 80,
+		OnEvict:   func(e Eviction) { evictions = append(evictions, e) },
+	})
+
+	if _, err := cv.Send(context.Background(), strings.Repeat("y", 200)); err == nil {
+		t.Fatal("Conversation.Send() should fail")
+	}
+	if len(evictions) != 0 || len(cv.Messages()) != 2 {
+		t.Fatalf("after a failed turn: evictions = %+v, %d messages, want none and 2", evictions, len(cv.Messages()))
+	}
+
+	fail = false
+	if _, err := cv.Send(context.Background(), strings.Repeat("y", 200)); err != nil {
+		t.Fatal(err)
+	}
+	if len(evictions) != 1 || len(evictions[0].Evicted) != 2 {
+		t.Errorf("evictions = %+v, want one eviction of the first turn", evictions)
+	}
+}
+
+func TestConversation_ContextWindow_NumCtx(t *testing.T) {
+	c := newTestServer(t, "test", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(chatResponse{
+			Model:   "test",
+			Message: messageResponse{Role: "assistant", Content: "ok"},
+			Done:    true,
+		})
+	})
+	// ~4000 tokens, over the default context length.
+	history := strings.Repeat("x", 16000)
+
+	tests := []struct {
+		name        string
+		client      Options
+		call        []ChatOption
+		wantEvicted bool
+	}{
+		{name: "Default context length", wantEvicted: true},
+		{name: "Client options", client: Options{NumCtx: Ptr(int64(32000))}},
+		{name: "Call options", call: []ChatOption{Options{NumCtx: Ptr(int64(32000))}}},
+		{name: "Call options override the client", client: Options{NumCtx: Ptr(int64(32000))}, call: []ChatOption{Options{NumCtx: Ptr(int64(1024))}}, wantEvicted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c.SetOptions(tt.client)
+
+			evicted := false
+			cv := c.NewConversation()
+			cv.AddUser(history)
+			cv.AddAssistant("ok")
+			cv.SetContextWindow(ContextWindow{
+				Strategy: DropOldest{},
+				OnEvict:  func(Eviction) { evicted = true },
+			})
+
+			if _, err := cv.Send(context.Background(), "and now?", tt.call...); err != nil {
+				t.Fatal(err)
+			}
+			if evicted != tt.wantEvicted {
+				t.Errorf("evicted = %v, want %v", evicted, tt.wantEvicted)
+			}
+		})
+	}
+}
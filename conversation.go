@@ -0,0 +1,218 @@
+package gollama
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Conversation keeps the message history of a multi-turn chat with a model.
+//
+// Every call to Send appends the user prompt and the assistant reply to the
+// history, so the model sees everything that was said before. The history
+// can be inspected and edited with Messages and SetMessages.
+//
+// A Conversation is safe to inspect from several goroutines, but turns
+// should be sent one at a time.
+type Conversation struct {
+	client    *Gollama
+	messages  []Message
+	createdAt time.Time
+	window    *ContextWindow
+	mu        sync.Mutex
+}
+
+// NewConversation creates a new conversation bound to the Gollama client.
+//
+// If the client has a SystemPrompt, it becomes the first message of the
+// conversation.
+func (c *Gollama) NewConversation() *Conversation {
+	cv := &Conversation{
+		client:   c,
+		messages: []Message{},
+	}
+
+	if c.SystemPrompt != "" {
+		cv.messages = append(cv.messages, Message{
+			Role:    RoleSystem,
+			Content: c.SystemPrompt,
+		})
+	}
+
+	return cv
+}
+
+// Send adds the prompt to the conversation as a user message and asks the
+// model for a reply, which is appended to the history as well.
+//
+// Options are the same as for Chat. PromptImage and Frames options are
+// attached to the user message, so they stay in the history.
+//
+// If the request fails, the history is left unchanged.
+func (cv *Conversation) Send(ctx context.Context, prompt string, options ...ChatOption) (*ChatOuput, error) {
+	prompt, images, options := splitPromptImages(prompt, options)
+	user := Message{
+		Role:    RoleUser,
+		Content: prompt,
+		Images:  images,
+	}
+
+	return cv.send(ctx, []Message{user}, nil, options)
+}
+
+// SendStream is like Send, but streams the reply like ChatStream.
+func (cv *Conversation) SendStream(ctx context.Context, prompt string, fn func(ChatChunk) error, options ...ChatOption) (*ChatOuput, error) {
+	prompt, images, options := splitPromptImages(prompt, options)
+	user := Message{
+		Role:    RoleUser,
+		Content: prompt,
+		Images:  images,
+	}
+
+	return cv.send(ctx, []Message{user}, fn, options)
+}
+
+// Continue asks the model for a reply to the current history without adding
+// a new user message, e.g. after adding tool results with AddTool.
+func (cv *Conversation) Continue(ctx context.Context, options ...ChatOption) (*ChatOuput, error) {
+	return cv.send(ctx, nil, nil, options)
+}
+
+// send sends the history plus the pending messages to the model. On success
+// the pending messages and the assistant reply are appended to the history,
+// and the messages evicted by the context window, if any, are removed.
+func (cv *Conversation) send(ctx context.Context, pending []Message, fn func(ChatChunk) error, options []ChatOption) (*ChatOuput, error) {
+	messages, reportEviction, err := cv.fitContext(ctx, append(cv.Messages(), pending...), options)
+	if err != nil {
+		return nil, err
+	}
+
+	var out *ChatOuput
+	if fn != nil {
+		out, err = cv.client.chatStream(ctx, messages, fn, options)
+	} else {
+		out, err = cv.client.chat(ctx, messages, options)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	role := out.Role
+	if role == "" {
+		role = RoleAssistant
+	}
+
+	cv.SetMessages(append(messages, Message{
+		Role:      role,
+		Content:   out.Content,
+		ToolCalls: out.ToolCalls,
+	}))
+
+	// Evictions are only reported once they took effect.
+	if reportEviction != nil {
+		reportEviction()
+	}
+
+	return out, nil
+}
+
+// Add appends messages to the history as they are. Images from readers
+// and decoded images are buffered, so they can be sent on every turn.
+func (cv *Conversation) Add(messages ...Message) {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+
+	cv.messages = append(cv.messages, bufferMessages(messages)...)
+}
+
+// AddSystem appends a system message to the history.
+func (cv *Conversation) AddSystem(content string) {
+	cv.Add(Message{Role: RoleSystem, Content: content})
+}
+
+// AddUser appends a user message, with optional images, to the history
+// without sending it.
+func (cv *Conversation) AddUser(content string, images ...PromptImage) {
+	cv.Add(Message{Role: RoleUser, Content: content, Images: images})
+}
+
+// AddAssistant appends an assistant message to the history.
+func (cv *Conversation) AddAssistant(content string) {
+	cv.Add(Message{Role: RoleAssistant, Content: content})
+}
+
+// AddTool appends the result of a tool call to the history.
+func (cv *Conversation) AddTool(content string) {
+	cv.Add(Message{Role: RoleTool, Content: content})
+}
+
+// AddToolResult appends the result of a tool call to the history, with the
+// name and id of the call it answers.
+func (cv *Conversation) AddToolResult(call ToolCall, content string) {
+	cv.Add(toolMessage(call, content))
+}
+
+// toolMessage returns the tool message that answers a call.
+func toolMessage(call ToolCall, content string) Message {
+	return Message{
+		Role:       RoleTool,
+		Content:    content,
+		ToolName:   call.Function.Name,
+		ToolCallID: call.ID,
+	}
+}
+
+// Messages returns a copy of the conversation history.
+func (cv *Conversation) Messages() []Message {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+
+	messages := make([]Message, len(cv.messages))
+	copy(messages, cv.messages)
+	return messages
+}
+
+// SetMessages replaces the conversation history.
+func (cv *Conversation) SetMessages(messages []Message) {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+
+	cv.messages = bufferMessages(messages)
+}
+
+// Reset removes every message from the history except the system messages.
+func (cv *Conversation) Reset() {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+
+	kept := []Message{}
+	for _, m := range cv.messages {
+		if m.Role == RoleSystem {
+			kept = append(kept, m)
+		}
+	}
+	cv.messages = kept
+}
+
+// toChatMessage converts a Message into the format expected by the Ollama
+// API, preprocessing and encoding its images.
+func (m Message) toChatMessage(preprocess *ImagePreprocess) (chatMessage, error) {
+	prepared, err := preprocessImages(m.Images, preprocess)
+	if err != nil {
+		return chatMessage{}, err
+	}
+
+	images, err := encodePromptImages(prepared)
+	if err != nil {
+		return chatMessage{}, err
+	}
+
+	return chatMessage{
+		Role:       m.Role,
+		Content:    m.Content,
+		Images:     images,
+		ToolCalls:  m.ToolCalls,
+		ToolName:   m.ToolName,
+		ToolCallID: m.ToolCallID,
+	}, nil
+}
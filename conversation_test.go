@@ -0,0 +1,143 @@
+package gollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestConversation_Send(t *testing.T) {
+	var got [][]chatMessage
+	replies := []string{"Hi Ana!", "Your name is Ana."}
+
+	c := newTestServer(t, "test", func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, req.Messages)
+		reply := replies[len(got)-1]
+		json.NewEncoder(w).Encode(chatResponse{
+			Model:   "test",
+			Message: messageResponse{Role: "assistant", Content: reply},
+			Done:    true,
+		})
+	})
+	c.SetSystemPrompt("Be brief.")
+
+	cv := c.NewConversation()
+	if _, err := cv.Send(context.Background(), "I am Ana"); err != nil {
+		t.Fatalf("Conversation.Send() error = %v", err)
+	}
+	out, err := cv.Send(context.Background(), "What is my name?")
+	if err != nil {
+		t.Fatalf("Conversation.Send() error = %v", err)
+	}
+	if out.Content != "Your name is Ana." {
+		t.Errorf("Conversation.Send() = %q", out.Content)
+	}
+
+	wantSent := []chatMessage{
+		{Role: "system", Content: "Be brief."},
+		{Role: "user", Content: "I am Ana"},
+		{Role: "assistant", Content: "Hi Ana!"},
+		{Role: "user", Content: "What is my name?"},
+	}
+	if !reflect.DeepEqual(got[1], wantSent) {
+		t.Errorf("second request messages = %+v, want %+v", got[1], wantSent)
+	}
+
+	if n := len(cv.Messages()); n != 5 {
+		t.Errorf("Conversation.Messages() has %d messages, want 5", n)
+	}
+
+	cv.Reset()
+	if msgs := cv.Messages(); len(msgs) != 1 || msgs[0].Role != RoleSystem {
+		t.Errorf("Conversation.Reset() left %+v", msgs)
+	}
+}
+
+func TestConversation_SendError(t *testing.T) {
+	c := newTestServer(t, "test", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"boom"}`, http.StatusInternalServerError)
+	})
+
+	cv := c.NewConversation()
+	if _, err := cv.Send(context.Background(), "hello"); err == nil {
+		t.Errorf("Conversation.Send() expected an error")
+	}
+	if n := len(cv.Messages()); n != 0 {
+		t.Errorf("Conversation.Messages() has %d messages after a failed send, want 0", n)
+	}
+}
+
+func TestConversation_ToolMessages(t *testing.T) {
+	var body []byte
+	c := newTestServer(t, "test", func(w http.ResponseWriter, r *http.Request) {
+		var raw map[string]json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+			t.Fatal(err)
+		}
+		body = raw["messages"]
+		json.NewEncoder(w).Encode(chatResponse{
+			Model:   "test",
+			Message: messageResponse{Role: "assistant", Content: "It is sunny"},
+			Done:    true,
+		})
+	})
+
+	call := ToolCall{
+		ID:       "call_1",
+		Function: ToolCallFunction{Index: 0, Name: "get_weather", Arguments: map[string]any{"city": "Paris"}},
+	}
+
+	cv := c.NewConversation()
+	cv.AddUser("Weather in Paris?")
+	cv.Add(Message{Role: RoleAssistant, ToolCalls: []ToolCall{call}})
+	cv.AddToolResult(call, "sunny")
+
+	if _, err := cv.Continue(context.Background()); err != nil {
+		t.Fatalf("Conversation.Continue() error = %v", err)
+	}
+
+	want := `[{"role":"user","content":"Weather in Paris?"},` +
+		`{"role":"assistant","content":"","tool_calls":[{"id":"call_1","function":{"name":"get_weather","arguments":{"city":"Paris"}}}]},` +
+		`{"role":"tool","content":"sunny","tool_name":"get_weather","tool_call_id":"call_1"}]`
+	if string(body) != want {
+		t.Errorf("messages = %s, want %s", body, want)
+	}
+}
+
+func TestConversation_AddUserReaderImage(t *testing.T) {
+	road, _ := os.ReadFile("./test/road.png")
+
+	var images [][]string
+	c := newTestServer(t, "test", func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		images = append(images, req.Messages[0].Images)
+		json.NewEncoder(w).Encode(chatResponse{
+			Model:   "test",
+			Message: messageResponse{Role: "assistant", Content: "A road."},
+			Done:    true,
+		})
+	})
+
+	cv := c.NewConversation()
+	cv.AddUser("look", ImageFromReader(bytes.NewReader(road)))
+	for i := 0; i < 2; i++ {
+		if _, err := cv.Continue(context.Background()); err != nil {
+			t.Fatalf("Conversation.Continue() error = %v", err)
+		}
+	}
+
+	if len(images) != 2 || len(images[1]) != 1 || images[1][0] != images[0][0] {
+		t.Errorf("the image was not sent again on the second turn")
+	}
+}
@@ -3,6 +3,7 @@ package gollama
 import (
 	"context"
 	"math"
+	"time"
 )
 
 // Embedding generates a vector embedding for a given string of text using the
@@ -14,10 +15,21 @@ import (
 //
 // The function returns a slice of floats, representing the vector
 // embedding of the input text.
-func (c *Gollama) Embedding(ctx context.Context, prompt string) ([]float64, error) {
+//
+// A KeepAlive duration can be passed as an option to control how long the
+// model stays loaded.
+func (c *Gollama) Embedding(ctx context.Context, prompt string, options ...ChatOption) ([]float64, error) {
+	var keepAlive *time.Duration
+	for _, option := range options {
+		if opt, ok := option.(KeepAlive); ok {
+			keepAlive = Ptr(time.Duration(opt))
+		}
+	}
+
 	req := embeddingsRequest{
-		Model:  c.ModelName,
-		Prompt: prompt,
+		Model:     c.ModelName,
+		Prompt:    prompt,
+		KeepAlive: c.keepAliveRequest(keepAlive),
 	}
 
 	var resp embeddingsResponse
@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/jonathanhecl/gollama"
 )
@@ -91,27 +92,26 @@ func main() {
 	prompt := "List the files in the current directory using the available tools."
 	fmt.Printf("\nSending prompt to LLM: %q\n", prompt)
 
-	// Pass the MCP client directly to Gollama (implements ToolSource interface)
-	// This is cleaner than calling ListTools manually
-	response, err := g.Chat(ctx, prompt, client)
-	if err != nil {
-		log.Fatalf("Chat failed: %v", err)
-	}
-
-	if len(response.ToolCalls) > 0 {
-		fmt.Println("\nModel requested tool execution:")
-		for _, toolCall := range response.ToolCalls {
-			fmt.Printf("Tool: %s\nArgs: %v\n", toolCall.Function.Name, toolCall.Function.Arguments)
-
-			// Execute the tool using the MCP client
-			result, err := client.CallTool(toolCall.Function.Name, toolCall.Function.Arguments)
-			if err != nil {
-				log.Printf("Tool execution failed: %v", err)
+	// Pass the MCP client to an agent (it implements ToolSource and
+	// ToolExecutor): the agent runs the tools the model asks for and sends
+	// the results back until the model answers.
+	agent := g.NewAgent(client)
+	agent.ToolTimeout = 30 * time.Second
+	agent.OnStep = func(step gollama.AgentStep) {
+		for _, res := range step.ToolResults {
+			fmt.Printf("Tool: %s\nArgs: %v\n", res.Call.Function.Name, res.Call.Function.Arguments)
+			if res.Err != nil {
+				log.Printf("Tool execution failed: %v", res.Err)
 				continue
 			}
-			fmt.Printf("Result: %s\n", result)
+			fmt.Printf("Result: %s\n", res.Result)
 		}
-	} else {
-		fmt.Println("\nModel response:", response.Content)
 	}
+
+	result, err := agent.Run(ctx, prompt)
+	if err != nil {
+		log.Fatalf("Agent failed: %v", err)
+	}
+
+	fmt.Println("\nModel response:", result.Output.Content)
 }
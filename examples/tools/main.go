@@ -5,12 +5,16 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
-	"strconv"
 	"syscall"
 
 	"github.com/jonathanhecl/gollama"
 )
 
+type sumArgs struct {
+	A float64 `json:"a" description:"first number" required:"true"`
+	B float64 `json:"b" description:"second number" required:"true"`
+}
+
 func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
@@ -23,11 +27,17 @@ func main() {
 
 	prompt := "what is 300 more 738.2?"
 
-	option := sumFunc()
-
-	fmt.Printf("Option: %+v\n", option)
+	tools := gollama.NewToolRegistry()
+	err := gollama.RegisterTool(tools, "func_sum", "Sum two numbers and return the result",
+		func(ctx context.Context, args sumArgs) (float64, error) {
+			return sum(args.A, args.B), nil
+		})
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
 
-	output, err := g.Chat(ctx, prompt, option)
+	output, err := g.Chat(ctx, prompt, tools)
 	if err != nil {
 		fmt.Println("Error:", err)
 		return
@@ -35,37 +45,16 @@ func main() {
 
 	fmt.Printf("\n%+v\n", output.ToolCalls)
 
-	if len(output.ToolCalls) > 0 {
-		for _, call := range output.ToolCalls {
-			fmt.Printf("Using tool: %+v\n", call)
-			if call.Function.Name == "func_sum" {
-				a := call.Function.Arguments["a"]
-				b := call.Function.Arguments["b"]
-
-				afloat, _ := strconv.ParseFloat(fmt.Sprintf("%v", a), 64)
-				bfloat, _ := strconv.ParseFloat(fmt.Sprintf("%v", b), 64)
+	for _, call := range output.ToolCalls {
+		fmt.Printf("Using tool: %+v\n", call)
 
-				fmt.Printf("Sum: %f + %f = %f\n", afloat, bfloat, sum(afloat, bfloat))
-			}
+		result, err := tools.Call(ctx, call)
+		if err != nil {
+			fmt.Println("Error:", err)
+			continue
 		}
-	}
-}
 
-func sumFunc() gollama.Tool {
-	return gollama.Tool{
-		Type: "function",
-		Function: gollama.ToolFunction{
-			Name:        "func_sum",
-			Description: "Sum two numbers and return the result",
-			Parameters: gollama.StructuredFormat{
-				Type: "object",
-				Properties: map[string]gollama.FormatProperty{
-					"a": {Type: "number", Description: "first number"},
-					"b": {Type: "number", Description: "second number"},
-				},
-				Required: []string{"a", "b"},
-			},
-		},
+		fmt.Printf("Result: %s\n", result)
 	}
 }
 
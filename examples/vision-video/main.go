@@ -15,10 +15,11 @@ import (
 
 const (
 	pathScreenshots = "./screenshots/"
+	maxFrames       = 8
 )
 
 var (
-	videoExt = []string{".mp4", ".mkv", ".webm"}
+	videoExt = []string{".mp4", ".mkv", ".webm", ".gif"}
 )
 
 func main() {
@@ -76,8 +77,10 @@ func main() {
 	for _, video := range videos {
 		fmt.Println(video.filename)
 
-		// Extract first frame
-		extractFrame(video.path, video.filename)
+		// Animated GIFs are decoded natively, videos need ffmpeg
+		if filepath.Ext(video.filename) != ".gif" {
+			extractFrame(video.path, video.filename)
+		}
 	}
 
 	fmt.Println("Total videos:", len(videos))
@@ -95,9 +98,18 @@ func main() {
 	for _, video := range videos {
 		fmt.Println("Processing video:", video.filename)
 
-		image := gollama.PromptImage{Filename: pathScreenshots + video.filename + ".jpg"}
+		var input gollama.ChatOption = gollama.PromptImage{Filename: pathScreenshots + video.filename + ".jpg"}
+
+		if filepath.Ext(video.filename) == ".gif" {
+			frames, err := gollama.FramesFromGIFFile(filepath.Join(video.path, video.filename))
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			input = gollama.SampleFrames(frames, maxFrames, gollama.SampleSceneChanges)
+		}
 
-		res, err := m.Chat(ctx, prompt, image)
+		res, err := m.Chat(ctx, prompt, input)
 		if err != nil {
 			fmt.Println(err)
 			return
@@ -1,19 +1,193 @@
 package gollama
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/base64"
 	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
 	"os"
+	"strings"
 )
 
-func base64EncodeFile(filePath string) (string, error) {
-	data, err := os.ReadFile(filePath)
+// sniffLen is the number of bytes http.DetectContentType looks at.
+const sniffLen = 512
+
+// ImageFromFile returns a PromptImage that reads the image from a file.
+func ImageFromFile(filename string) PromptImage {
+	return PromptImage{Filename: filename}
+}
+
+// ImageFromBytes returns a PromptImage for an encoded image (PNG, JPEG, ...)
+// held in memory.
+func ImageFromBytes(data []byte) PromptImage {
+	return PromptImage{Data: data}
+}
+
+// ImageFromReader returns a PromptImage that reads an encoded image from r.
+// The reader is read once, when the image is attached to a prompt or a
+// history, and the bytes are kept so the image can be sent again.
+func ImageFromReader(r io.Reader) PromptImage {
+	return PromptImage{Reader: r}
+}
+
+// ImageFromImage returns a PromptImage for a decoded image. It is encoded
+// as PNG when the image is attached to a prompt or a history.
+func ImageFromImage(img image.Image) PromptImage {
+	return PromptImage{Image: img}
+}
+
+// name describes the image in error messages.
+func (p PromptImage) name() string {
+	switch {
+	case p.Filename != "":
+		return p.Filename
+	case p.Data != nil:
+		return "image data"
+	case p.Reader != nil:
+		return "image reader"
+	default:
+		return "image"
+	}
+}
+
+// open returns a reader over the encoded image.
+func (p PromptImage) open() (io.Reader, func() error, error) {
+	noop := func() error { return nil }
+
+	switch {
+	case p.err != nil:
+		return nil, nil, p.err
+	case p.Image != nil:
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(png.Encode(pw, p.Image))
+		}()
+		return pr, pr.Close, nil
+	case p.Data != nil:
+		return bytes.NewReader(p.Data), noop, nil
+	case p.Reader != nil:
+		return p.Reader, noop, nil
+	case p.Filename != "":
+		f, err := os.Open(p.Filename)
+		if err != nil {
+			return nil, nil, err
+		}
+		return f, f.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("prompt image has no source")
+	}
+}
+
+// encodePromptImage encodes the image as a base64 string, as expected by the
+// Ollama API.
+//
+// The image is streamed through the base64 encoder, so only the encoded
+// string is held in memory. Data that is not an image is rejected.
+func encodePromptImage(p PromptImage) (string, error) {
+	r, closeFn, err := p.open()
 	if err != nil {
 		return "", err
 	}
-	if len(data) == 0 {
-		return "", fmt.Errorf("%s contains 0 bytes", filePath)
+	defer closeFn()
+
+	br := bufio.NewReaderSize(r, sniffLen)
+	head, err := br.Peek(sniffLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return "", err
+	}
+	if len(head) == 0 {
+		return "", fmt.Errorf("%s contains 0 bytes", p.name())
+	}
+
+	if mime := http.DetectContentType(head); !strings.HasPrefix(mime, "image/") {
+		return "", fmt.Errorf("%s is not an image (%s)", p.name(), mime)
 	}
-	encoded := base64.StdEncoding.EncodeToString(data)
+
+	var sb strings.Builder
+	encoder := base64.NewEncoder(base64.StdEncoding, &sb)
+	if _, err := io.Copy(encoder, br); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+
+	return sb.String(), nil
+}
+
+// buffered returns the image with its Reader or Image source replaced by
+// the encoded bytes, so it can be sent again and stored as JSON.
+func (p PromptImage) buffered() (PromptImage, error) {
+	if p.Reader == nil && p.Image == nil {
+		return p, nil
+	}
+
+	r, closeFn, err := p.open()
+	if err != nil {
+		return PromptImage{}, err
+	}
+	defer closeFn()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return PromptImage{}, err
+	}
+
+	return PromptImage{Data: data}, nil
+}
+
+// bufferImages returns the images with their Reader and Image sources
+// buffered, so they can be sent more than once and stored in a session. An
+// image that can not be read keeps the error, which is returned when it is
+// sent.
+func bufferImages(images []PromptImage) []PromptImage {
+	if len(images) == 0 {
+		return images
+	}
+
+	buffered := make([]PromptImage, 0, len(images))
+	for _, image := range images {
+		b, err := image.buffered()
+		if err != nil {
+			b = PromptImage{err: fmt.Errorf("%s: %w", image.name(), err)}
+		}
+		buffered = append(buffered, b)
+	}
+
+	return buffered
+}
+
+// bufferMessages returns a copy of the messages with their images
+// buffered like bufferImages does.
+func bufferMessages(messages []Message) []Message {
+	buffered := make([]Message, len(messages))
+	for i, m := range messages {
+		m.Images = bufferImages(m.Images)
+		buffered[i] = m
+	}
+
+	return buffered
+}
+
+// encodePromptImages encodes the images as base64 strings, as expected by
+// the Ollama API. It returns nil when there are no images.
+func encodePromptImages(images []PromptImage) ([]string, error) {
+	if len(images) == 0 {
+		return nil, nil
+	}
+
+	encoded := make([]string, 0, len(images))
+	for _, image := range images {
+		base64image, err := encodePromptImage(image)
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, base64image)
+	}
+
 	return encoded, nil
 }
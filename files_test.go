@@ -0,0 +1,73 @@
+package gollama
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncodePromptImage(t *testing.T) {
+	road, err := os.ReadFile("./test/road.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, color.RGBA{255, 0, 0, 255})
+	var imgPNG bytes.Buffer
+	png.Encode(&imgPNG, img)
+
+	empty := filepath.Join(t.TempDir(), "empty.png")
+	os.WriteFile(empty, nil, 0o644)
+
+	tests := []struct {
+		name    string
+		image   PromptImage
+		want    []byte
+		wantErr string
+	}{
+		{name: "File", image: ImageFromFile("./test/road.png"), want: road},
+		{name: "Bytes", image: ImageFromBytes(road), want: road},
+		{name: "Reader", image: ImageFromReader(bytes.NewReader(road)), want: road},
+		{name: "Image", image: ImageFromImage(img), want: imgPNG.Bytes()},
+		{name: "Not an image", image: ImageFromBytes([]byte("hello world")), wantErr: "not an image"},
+		{name: "Empty file", image: ImageFromFile(empty), wantErr: "0 bytes"},
+		{name: "Missing file", image: ImageFromFile("./test/missing.png"), wantErr: "no such file"},
+		{name: "No source", image: PromptImage{}, wantErr: "no source"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := encodePromptImage(tt.image)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("encodePromptImage() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("encodePromptImage() error = %v", err)
+			}
+			if got != base64.StdEncoding.EncodeToString(tt.want) {
+				t.Errorf("encodePromptImage() returned a different encoding")
+			}
+		})
+	}
+}
+
+func TestPromptImage_buffered(t *testing.T) {
+	road, _ := os.ReadFile("./test/road.png")
+
+	got, err := ImageFromReader(bytes.NewReader(road)).buffered()
+	if err != nil {
+		t.Fatalf("PromptImage.buffered() error = %v", err)
+	}
+	if got.Reader != nil || !bytes.Equal(got.Data, road) {
+		t.Errorf("PromptImage.buffered() did not buffer the reader")
+	}
+}
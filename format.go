@@ -0,0 +1,65 @@
+package gollama
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// FormatMode is a format given by name, like FormatJSON.
+type FormatMode string
+
+// FormatJSON asks the model for any valid JSON, without a schema.
+const FormatJSON FormatMode = "json"
+
+// LoadSchemaFile reads a JSON schema from a file, to use as the format of
+// a Chat or Generate. The schema is sent as it is, so it may use keywords
+// that StructuredFormat does not have, like anyOf or an array root.
+func LoadSchemaFile(path string) (json.RawMessage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("error decoding schema %s: invalid JSON", path)
+	}
+
+	return json.RawMessage(data), nil
+}
+
+// requestFormat returns the value of the format field of a request for an
+// option, and whether the option is a format. A StructuredFormat without
+// properties and an empty schema do not set a format. A plain string is
+// taken as a FormatMode, and only "json" is accepted.
+func requestFormat(option ChatOption) (json.RawMessage, bool, error) {
+	switch opt := option.(type) {
+	case string:
+		if FormatMode(opt) != FormatJSON {
+			return nil, true, fmt.Errorf("invalid format %q: want %q", opt, FormatJSON)
+		}
+		return requestFormat(FormatMode(opt))
+	case FormatMode:
+		format, err := json.Marshal(string(opt))
+		return format, true, err
+	case StructuredFormat:
+		if opt.err != nil {
+			return nil, true, fmt.Errorf("invalid structured format: %w", opt.err)
+		}
+		if len(opt.Properties) == 0 {
+			return nil, true, nil
+		}
+		format, err := json.Marshal(opt)
+		return format, true, err
+	case json.RawMessage:
+		if len(opt) == 0 {
+			return nil, true, nil
+		}
+		if !json.Valid(opt) {
+			return nil, true, fmt.Errorf("invalid format: not valid JSON")
+		}
+		return opt, true, nil
+	}
+
+	return nil, false, nil
+}
@@ -0,0 +1,120 @@
+package gollama
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGollama_newChatRequest_Format(t *testing.T) {
+	anyOf := json.RawMessage(`{"anyOf": [{"type": "string"}, {"type": "array", "items": {"type": "integer"}}]}`)
+
+	tests := []struct {
+		name    string
+		options []ChatOption
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "No format",
+			want: "",
+		},
+		{
+			name:    "JSON mode",
+			options: []ChatOption{FormatJSON},
+			want:    `"json"`,
+		},
+		{
+			name:    "JSON mode as a string",
+			options: []ChatOption{"json"},
+			want:    `"json"`,
+		},
+		{
+			name:    "Unknown string",
+			options: []ChatOption{"yaml"},
+			wantErr: true,
+		},
+		{
+			name:    "Empty StructuredFormat",
+			options: []ChatOption{StructuredFormat{}},
+			want:    "",
+		},
+		{
+			name: "StructuredFormat",
+			options: []ChatOption{StructuredFormat{
+				Type:       "object",
+				Properties: map[string]FormatProperty{"name": {Type: "string"}},
+			}},
+			want: `{"type":"object","properties":{"name":{"type":"string"}}}`,
+		},
+		{
+			name:    "Raw schema sent as it is",
+			options: []ChatOption{anyOf},
+			want:    string(anyOf),
+		},
+		{
+			name:    "Last format wins",
+			options: []ChatOption{anyOf, FormatJSON},
+			want:    `"json"`,
+		},
+		{
+			name:    "Invalid raw schema",
+			options: []ChatOption{json.RawMessage(`{"type":`)},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New("test")
+
+			req, err := c.newChatRequest([]Message{{Role: RoleUser, Content: "hi"}}, tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newChatRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(req.Format) != tt.want {
+				t.Errorf("newChatRequest() format = %s, want %s", req.Format, tt.want)
+			}
+
+			body, _ := json.Marshal(req)
+			var sent struct {
+				Format json.RawMessage `json:"format"`
+			}
+			json.Unmarshal(body, &sent)
+			want := tt.want
+			if want != "" {
+				var compact bytes.Buffer
+				json.Compact(&compact, []byte(want))
+				want = compact.String()
+			}
+			if string(sent.Format) != want {
+				t.Errorf("sent format = %s, want %s", sent.Format, want)
+			}
+		})
+	}
+}
+
+func TestLoadSchemaFile(t *testing.T) {
+	dir := t.TempDir()
+
+	schema := "{\n  \"type\": \"array\",\n  \"items\": {\"type\": \"string\"}\n}\n"
+	valid := filepath.Join(dir, "schema.json")
+	os.WriteFile(valid, []byte(schema), 0o644)
+
+	got, err := LoadSchemaFile(valid)
+	if err != nil || string(got) != schema {
+		t.Errorf("LoadSchemaFile() = %s, %v", got, err)
+	}
+
+	invalid := filepath.Join(dir, "invalid.json")
+	os.WriteFile(invalid, []byte(`{"type":`), 0o644)
+
+	if _, err := LoadSchemaFile(invalid); err == nil {
+		t.Errorf("LoadSchemaFile() of invalid JSON, want an error")
+	}
+	if _, err := LoadSchemaFile(filepath.Join(dir, "missing.json")); err == nil {
+		t.Errorf("LoadSchemaFile() of a missing file, want an error")
+	}
+}
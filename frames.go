@@ -0,0 +1,250 @@
+package gollama
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// sceneSampleSize is the side of the thumbnails compared to detect scene
+	// changes.
+	sceneSampleSize = 32
+)
+
+// Frame is a single frame of an animation or an image sequence.
+type Frame struct {
+	Image     image.Image
+	Timestamp time.Duration
+}
+
+// Frames is an ordered sequence of frames. Pass it as a ChatOption to send
+// the frames as images, with their timestamps described in the prompt, so a
+// vision model can reason about motion and changes over time.
+//
+// Models handle only a few images per request, so long sequences should be
+// reduced with SampleFrames first.
+type Frames []Frame
+
+// FrameSampling is the way SampleFrames picks frames.
+type FrameSampling int
+
+const (
+	// SampleEvenly picks frames evenly spaced over the sequence.
+	SampleEvenly FrameSampling = iota
+	// SampleSceneChanges picks the first frame and the frames that differ
+	// the most from the frame before them.
+	SampleSceneChanges
+)
+
+// FramesFromGIF decodes every frame of an animated GIF. The frames are
+// composed as a viewer would show them, and timestamped with the frame
+// delays.
+func FramesFromGIF(r io.Reader) (Frames, error) {
+	g, err := gif.DecodeAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding gif: %w", err)
+	}
+
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if bounds.Empty() && len(g.Image) > 0 {
+		bounds = g.Image[0].Bounds()
+	}
+
+	var (
+		canvas    = image.NewRGBA(bounds)
+		frames    = make(Frames, 0, len(g.Image))
+		timestamp time.Duration
+	)
+
+	for i, img := range g.Image {
+		var previous *image.RGBA
+		if i < len(g.Disposal) && g.Disposal[i] == gif.DisposalPrevious {
+			previous = image.NewRGBA(bounds)
+			copy(previous.Pix, canvas.Pix)
+		}
+
+		draw.Draw(canvas, img.Bounds(), img, img.Bounds().Min, draw.Over)
+
+		frame := image.NewRGBA(bounds)
+		copy(frame.Pix, canvas.Pix)
+		frames = append(frames, Frame{Image: frame, Timestamp: timestamp})
+
+		if i < len(g.Delay) {
+			timestamp += time.Duration(g.Delay[i]) * 10 * time.Millisecond
+		}
+
+		if i < len(g.Disposal) {
+			switch g.Disposal[i] {
+			case gif.DisposalBackground:
+				draw.Draw(canvas, img.Bounds(), image.Transparent, image.Point{}, draw.Src)
+			case gif.DisposalPrevious:
+				canvas = previous
+			}
+		}
+	}
+
+	return frames, nil
+}
+
+// FramesFromGIFFile decodes every frame of an animated GIF file, like
+// FramesFromGIF.
+func FramesFromGIFFile(filename string) (Frames, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return FramesFromGIF(f)
+}
+
+// FramesFromImages returns the images as a sequence of frames taken every
+// interval.
+func FramesFromImages(images []image.Image, interval time.Duration) Frames {
+	frames := make(Frames, 0, len(images))
+	for i, img := range images {
+		frames = append(frames, Frame{Image: img, Timestamp: time.Duration(i) * interval})
+	}
+	return frames
+}
+
+// FramesFromFiles decodes the image files, in order, as a sequence of frames
+// taken every interval.
+func FramesFromFiles(filenames []string, interval time.Duration) (Frames, error) {
+	images := make([]image.Image, 0, len(filenames))
+	for _, filename := range filenames {
+		img, err := decodeImageFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding %s: %w", filename, err)
+		}
+		images = append(images, img)
+	}
+
+	return FramesFromImages(images, interval), nil
+}
+
+func decodeImageFile(filename string) (image.Image, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+// SampleFrames picks at most n frames of the sequence, keeping their order.
+// The whole sequence is returned when it has n frames or less.
+func SampleFrames(frames Frames, n int, sampling FrameSampling) Frames {
+	if n <= 0 {
+		return Frames{}
+	}
+	if len(frames) <= n {
+		return frames
+	}
+
+	var indexes []int
+	switch sampling {
+	case SampleSceneChanges:
+		indexes = sceneChangeIndexes(frames, n)
+	default:
+		indexes = evenIndexes(len(frames), n)
+	}
+
+	sampled := make(Frames, 0, len(indexes))
+	for _, i := range indexes {
+		sampled = append(sampled, frames[i])
+	}
+	return sampled
+}
+
+// evenIndexes returns n indexes evenly spaced over [0, total), including the
+// first and the last one.
+func evenIndexes(total, n int) []int {
+	if n == 1 {
+		return []int{0}
+	}
+
+	indexes := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		indexes = append(indexes, i*(total-1)/(n-1))
+	}
+	return indexes
+}
+
+// sceneChangeIndexes returns the index of the first frame and of the n-1
+// frames that differ the most from the frame before them, in order.
+func sceneChangeIndexes(frames Frames, n int) []int {
+	type change struct {
+		index int
+		diff  float64
+	}
+
+	changes := make([]change, 0, len(frames)-1)
+	previous := resizeTo(frames[0].Image, sceneSampleSize, sceneSampleSize)
+	for i := 1; i < len(frames); i++ {
+		current := resizeTo(frames[i].Image, sceneSampleSize, sceneSampleSize)
+		changes = append(changes, change{index: i, diff: frameDiff(previous, current)})
+		previous = current
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].diff > changes[j].diff
+	})
+
+	indexes := []int{0}
+	for _, c := range changes[:n-1] {
+		indexes = append(indexes, c.index)
+	}
+	sort.Ints(indexes)
+
+	return indexes
+}
+
+// frameDiff returns the mean absolute difference between the pixels of two
+// images of the same size, from 0 to 255.
+func frameDiff(a, b *image.RGBA) float64 {
+	var total int
+	for i := range a.Pix {
+		d := int(a.Pix[i]) - int(b.Pix[i])
+		if d < 0 {
+			d = -d
+		}
+		total += d
+	}
+	return float64(total) / float64(len(a.Pix))
+}
+
+// promptImages returns the frames as images.
+func (f Frames) promptImages() []PromptImage {
+	images := make([]PromptImage, 0, len(f))
+	for _, frame := range f {
+		images = append(images, ImageFromImage(frame.Image))
+	}
+	return images
+}
+
+// describe returns the text added before the prompt to tell the model the
+// images are frames and when they were taken. first is the number of the
+// first frame among all the images sent.
+func (f Frames) describe(first int) string {
+	timestamps := make([]string, 0, len(f))
+	for i, frame := range f {
+		timestamps = append(timestamps, fmt.Sprintf("image %d at %.2fs", first+i, frame.Timestamp.Seconds()))
+	}
+
+	if first == 1 {
+		return fmt.Sprintf("The attached images are frames of a video, in chronological order: %s.",
+			strings.Join(timestamps, ", "))
+	}
+	return fmt.Sprintf("Images %d to %d are frames of a video, in chronological order: %s.",
+		first, first+len(f)-1, strings.Join(timestamps, ", "))
+}
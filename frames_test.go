@@ -0,0 +1,146 @@
+package gollama
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// solidFrame returns a w x h paletted image filled with c.
+func solidFrame(w, h int, c color.Color) *image.Paletted {
+	img := image.NewPaletted(image.Rect(0, 0, w, h), palette.Plan9)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestFramesFromGIF(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+
+	// A 4x4 red frame, then a 2x2 blue patch drawn over it.
+	patch := solidFrame(2, 2, blue)
+	patch.Rect = image.Rect(2, 2, 4, 4)
+
+	var buf bytes.Buffer
+	err := gif.EncodeAll(&buf, &gif.GIF{
+		Image:    []*image.Paletted{solidFrame(4, 4, red), patch},
+		Delay:    []int{50, 25},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalNone},
+		Config:   image.Config{Width: 4, Height: 4, ColorModel: color.Palette(palette.Plan9)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frames, err := FramesFromGIF(&buf)
+	if err != nil {
+		t.Fatalf("FramesFromGIF() error = %v", err)
+	}
+
+	if len(frames) != 2 {
+		t.Fatalf("len(frames) = %d, want 2", len(frames))
+	}
+	if frames[0].Timestamp != 0 || frames[1].Timestamp != 500*time.Millisecond {
+		t.Errorf("timestamps = %v, %v, want 0s, 500ms", frames[0].Timestamp, frames[1].Timestamp)
+	}
+
+	second := frames[1].Image
+	if second.Bounds() != image.Rect(0, 0, 4, 4) {
+		t.Errorf("bounds = %v, want the full canvas", second.Bounds())
+	}
+	if got := color.RGBAModel.Convert(second.At(0, 0)); got != red {
+		t.Errorf("pixel (0,0) = %v, want %v (kept from the first frame)", got, red)
+	}
+	if got := color.RGBAModel.Convert(second.At(3, 3)); got != blue {
+		t.Errorf("pixel (3,3) = %v, want %v", got, blue)
+	}
+}
+
+func TestSampleFrames(t *testing.T) {
+	black := color.RGBA{0, 0, 0, 255}
+	white := color.RGBA{255, 255, 255, 255}
+
+	// Ten frames: a cut to white at frame 4 and back to black at frame 7.
+	images := []image.Image{}
+	for i := 0; i < 10; i++ {
+		c := black
+		if i >= 4 && i < 7 {
+			c = white
+		}
+		images = append(images, solidFrame(8, 8, c))
+	}
+	frames := FramesFromImages(images, time.Second)
+
+	tests := []struct {
+		name     string
+		n        int
+		sampling FrameSampling
+		want     []time.Duration
+	}{
+		{
+			name:     "Evenly",
+			n:        4,
+			sampling: SampleEvenly,
+			want:     []time.Duration{0, 3 * time.Second, 6 * time.Second, 9 * time.Second},
+		},
+		{
+			name:     "Scene changes",
+			n:        3,
+			sampling: SampleSceneChanges,
+			want:     []time.Duration{0, 4 * time.Second, 7 * time.Second},
+		},
+		{
+			name:     "Fewer frames than n",
+			n:        20,
+			sampling: SampleEvenly,
+			want:     []time.Duration{0, 1 * time.Second, 2 * time.Second, 3 * time.Second, 4 * time.Second, 5 * time.Second, 6 * time.Second, 7 * time.Second, 8 * time.Second, 9 * time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []time.Duration{}
+			for _, f := range SampleFrames(frames, tt.n, tt.sampling) {
+				got = append(got, f.Timestamp)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SampleFrames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitPromptImages_Frames(t *testing.T) {
+	frames := FramesFromImages([]image.Image{
+		solidFrame(2, 2, color.Black),
+		solidFrame(2, 2, color.White),
+	}, 1500*time.Millisecond)
+
+	prompt, images, rest := splitPromptImages("What happens?", []ChatOption{
+		ImageFromBytes([]byte("x")),
+		frames,
+		Think(true),
+	})
+
+	if len(images) != 3 {
+		t.Errorf("len(images) = %d, want 3", len(images))
+	}
+	if len(rest) != 1 {
+		t.Errorf("len(rest) = %d, want 1", len(rest))
+	}
+
+	want := "Images 2 to 3 are frames of a video, in chronological order: image 2 at 0.00s, image 3 at 1.50s."
+	if !strings.HasPrefix(prompt, want) || !strings.HasSuffix(prompt, "\n\nWhat happens?") {
+		t.Errorf("prompt = %q", prompt)
+	}
+}
@@ -0,0 +1,230 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Generate generates a completion for a prompt using the /api/generate
+// endpoint of the Ollama API.
+//
+// Unlike Chat, there is no message history: the prompt is completed as it
+// is. The sampling settings and the SystemPrompt of the Gollama object are
+// used.
+//
+// The function takes a variable number of options as arguments. The options are:
+//   - PromptImage objects to pass as vision input.
+//   - Frames of an animation or video, sent as images with their timestamps.
+//   - A StructuredFormat, FormatJSON (or "json") or a json.RawMessage schema to constrain the output.
+//   - A Suffix for fill-in-the-middle completion.
+//   - Raw(true) to send the prompt without applying the model template.
+//   - A Template to override the model template.
+//   - A PromptContext returned by a previous call, to continue from it.
+//   - Options to override the model parameters for this call.
+//   - Think(true) or Think(false) to enable or disable the reasoning of thinking models.
+//   - A KeepAlive duration to control how long the model stays loaded.
+//   - Logprobs to return the log-probability of every generated token.
+//   - ImagePreprocess to resize, convert or tile the images before sending them.
+//
+// The response is trimmed if TrimSpace is set, except when a Suffix is used,
+// since whitespace matters for fill-in-the-middle completion.
+func (c *Gollama) Generate(ctx context.Context, prompt string, options ...ChatOption) (*GenerateOutput, error) {
+	req, err := c.newGenerateRequest(prompt, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp generateResponse
+	err = c.apiPost(ctx, "/api/generate", &resp, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Model != c.ModelName {
+		return nil, fmt.Errorf("model not found")
+	}
+
+	return c.newGenerateOutput(req, resp), nil
+}
+
+// GenerateStream generates a completion like Generate, but streams it.
+//
+// The function fn is called for every chunk received from the server, in
+// order. Returning an error from fn stops the stream and GenerateStream
+// returns that error. Cancelling ctx makes the server stop generating.
+//
+// Once the stream is done, GenerateStream returns the whole completion, just
+// like Generate.
+func (c *Gollama) GenerateStream(ctx context.Context, prompt string, fn func(GenerateChunk) error, options ...ChatOption) (*GenerateOutput, error) {
+	req, err := c.newGenerateRequest(prompt, options)
+	if err != nil {
+		return nil, err
+	}
+	req.Stream = true
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		final    generateResponse
+		response strings.Builder
+		thinking strings.Builder
+		logprobs []TokenLogprob
+		// A raw or fill-in-the-middle completion is never reasoning.
+		splitter = thinkSplitter{done: req.Raw || req.Suffix != ""}
+	)
+
+	err = c.apiPostStream(ctx, "/api/generate", req, func(line []byte) error {
+		var resp generateResponse
+		if err := json.Unmarshal(line, &resp); err != nil {
+			return fmt.Errorf("error decoding stream chunk: %w", err)
+		}
+
+		if resp.Error != "" {
+			return fmt.Errorf("ollama: %s", resp.Error)
+		}
+
+		chunk := GenerateChunk{
+			Thinking: resp.Thinking,
+			Logprobs: resp.Logprobs,
+			Done:     resp.Done,
+		}
+
+		inlineThinking, delta := splitter.split(resp.Response)
+		if resp.Done {
+			t, r := splitter.flush()
+			inlineThinking += t
+			delta += r
+			final = resp
+		}
+		chunk.Thinking += inlineThinking
+		chunk.Response = delta
+
+		response.WriteString(chunk.Response)
+		thinking.WriteString(chunk.Thinking)
+		logprobs = append(logprobs, chunk.Logprobs...)
+
+		if fn == nil {
+			return nil
+		}
+
+		return fn(chunk)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !final.Done {
+		return nil, fmt.Errorf("stream ended before the response was done")
+	}
+
+	if final.Model != c.ModelName {
+		return nil, fmt.Errorf("model not found")
+	}
+
+	final.Response = response.String()
+	final.Thinking = thinking.String()
+	final.Logprobs = logprobs
+	return c.newGenerateOutput(req, final), nil
+}
+
+// newGenerateRequest builds the request body sent to /api/generate for the
+// given prompt and options.
+func (c *Gollama) newGenerateRequest(prompt string, options []ChatOption) (generateRequest, error) {
+	prompt, images, options := splitPromptImages(prompt, options)
+
+	req := generateRequest{
+		Model:  c.ModelName,
+		Prompt: prompt,
+		System: c.SystemPrompt,
+		Stream: false,
+	}
+
+	var (
+		callOptions = Options{}
+		keepAlive   *time.Duration
+	)
+
+	for _, option := range options {
+		if format, ok, err := requestFormat(option); ok {
+			if err != nil {
+				return generateRequest{}, err
+			}
+			req.Format = format
+			continue
+		}
+
+		switch opt := option.(type) {
+		case Suffix:
+			req.Suffix = string(opt)
+		case Raw:
+			req.Raw = bool(opt)
+		case Template:
+			req.Template = string(opt)
+		case PromptContext:
+			req.Context = opt
+		case Options:
+			callOptions = callOptions.Merge(opt)
+		case Think:
+			req.Think = Ptr(bool(opt))
+		case KeepAlive:
+			keepAlive = Ptr(time.Duration(opt))
+		case Logprobs:
+			req.Logprobs = true
+			req.TopLogprobs = opt.TopN
+		default:
+			continue
+		}
+	}
+
+	req.KeepAlive = c.keepAliveRequest(keepAlive)
+
+	req.Options = c.newOptionsRequest(callOptions)
+
+	images, err := preprocessImages(images, c.imagePreprocessFor(options))
+	if err != nil {
+		return generateRequest{}, err
+	}
+
+	encoded, err := encodePromptImages(images)
+	if err != nil {
+		return generateRequest{}, err
+	}
+	req.Images = encoded
+
+	return req, nil
+}
+
+// newGenerateOutput converts a final /api/generate response into a
+// GenerateOutput.
+func (c *Gollama) newGenerateOutput(req generateRequest, resp generateResponse) *GenerateOutput {
+	thinking, response := "", resp.Response
+	if !req.Raw && req.Suffix == "" {
+		thinking, response = splitThinking(resp.Response, req.Think != nil && *req.Think)
+	}
+
+	out := &GenerateOutput{
+		Response:           response,
+		Thinking:           strings.TrimSpace(resp.Thinking + thinking),
+		Context:            resp.Context,
+		PromptTokens:       resp.PromptEvalCount,
+		ResponseTokens:     resp.EvalCount,
+		Logprobs:           resp.Logprobs,
+		Model:              resp.Model,
+		CreatedAt:          parseTime(resp.CreatedAt),
+		DoneReason:         resp.DoneReason,
+		TotalDuration:      time.Duration(resp.TotalDuration),
+		LoadDuration:       time.Duration(resp.LoadDuration),
+		PromptEvalDuration: time.Duration(resp.PromptEvalDuration),
+		EvalDuration:       time.Duration(resp.EvalDuration),
+	}
+
+	if c.TrimSpace && req.Suffix == "" {
+		out.Response = strings.TrimSpace(out.Response)
+	}
+
+	return out
+}
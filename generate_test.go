@@ -0,0 +1,130 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestGollama_Generate(t *testing.T) {
+	type args struct {
+		Prompt  string
+		Options []ChatOption
+	}
+	tests := []struct {
+		name     string
+		args     args
+		resp     generateResponse
+		wantReq  generateRequest
+		wantResp string
+		wantErr  bool
+	}{
+		{
+			name: "Fill in the middle",
+			args: args{Prompt: "def add(a, b):\n", Options: []ChatOption{Suffix("\n\nprint(add(1, 2))")}},
+			resp: generateResponse{Model: "test", Response: "    return a + b", Done: true, Context: []int{1, 2, 3}},
+			wantReq: generateRequest{
+				Model:  "test",
+				Prompt: "def add(a, b):\n",
+				Suffix: "\n\nprint(add(1, 2))",
+			},
+			wantResp: "    return a + b",
+		},
+		{
+			name: "Raw with context",
+			args: args{Prompt: "[INST] hi [/INST]", Options: []ChatOption{Raw(true), Template("{{ .Prompt }}"), PromptContext{1, 2, 3}}},
+			resp: generateResponse{Model: "test", Response: " hello ", Done: true},
+			wantReq: generateRequest{
+				Model:    "test",
+				Prompt:   "[INST] hi [/INST]",
+				Raw:      true,
+				Template: "{{ .Prompt }}",
+				Context:  []int{1, 2, 3},
+			},
+			wantResp: "hello",
+		},
+		{
+			name: "Raw completion with a tag",
+			args: args{Prompt: "x = \"", Options: []ChatOption{Raw(true)}},
+			resp: generateResponse{Model: "test", Response: "<think>\"", Done: true},
+			wantReq: generateRequest{
+				Model:  "test",
+				Prompt: "x = \"",
+				Raw:    true,
+			},
+			wantResp: "<think>\"",
+		},
+		{
+			name: "JSON mode",
+			args: args{Prompt: "List 3 colors", Options: []ChatOption{FormatJSON}},
+			resp: generateResponse{Model: "test", Response: `["red","green","blue"]`, Done: true},
+			wantReq: generateRequest{
+				Model:  "test",
+				Prompt: "List 3 colors",
+				Format: json.RawMessage(`"json"`),
+			},
+			wantResp: `["red","green","blue"]`,
+		},
+		{
+			name:    "Invalid model",
+			args:    args{Prompt: "hi"},
+			resp:    generateResponse{},
+			wantReq: generateRequest{Model: "test", Prompt: "hi"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotReq generateRequest
+			c := newTestServer(t, "test", func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/generate" {
+					t.Errorf("request to %s, want /api/generate", r.URL.Path)
+				}
+				json.NewDecoder(r.Body).Decode(&gotReq)
+				json.NewEncoder(w).Encode(tt.resp)
+			})
+
+			got, err := c.Generate(context.Background(), tt.args.Prompt, tt.args.Options...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Gollama.Generate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			tt.wantReq.Options = c.newOptionsRequest(Options{})
+			if !reflect.DeepEqual(gotReq, tt.wantReq) {
+				t.Errorf("Gollama.Generate() request = %+v, want %+v", gotReq, tt.wantReq)
+			}
+
+			if got != nil && got.Response != tt.wantResp {
+				t.Errorf("Gollama.Generate() = %q, want %q", got.Response, tt.wantResp)
+			}
+		})
+	}
+}
+
+func TestGollama_GenerateStream(t *testing.T) {
+	c := newTestServer(t, "test", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"model":"test","response":"Once","done":false}`)
+		fmt.Fprintln(w, `{"model":"test","response":" upon","done":false}`)
+		fmt.Fprintln(w, `{"model":"test","response":"","done":true,"context":[7,8],"eval_count":2}`)
+	})
+
+	var chunks []string
+	got, err := c.GenerateStream(context.Background(), "tell me a story", func(chunk GenerateChunk) error {
+		chunks = append(chunks, chunk.Response)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Gollama.GenerateStream() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(chunks, []string{"Once", " upon", ""}) {
+		t.Errorf("Gollama.GenerateStream() chunks = %q", chunks)
+	}
+	if got.Response != "Once upon" || !reflect.DeepEqual(got.Context, []int{7, 8}) || got.ResponseTokens != 2 {
+		t.Errorf("Gollama.GenerateStream() = %+v", got)
+	}
+}
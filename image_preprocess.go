@@ -0,0 +1,397 @@
+package gollama
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // register the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+const (
+	ImageFormatPNG  = "png"
+	ImageFormatJPEG = "jpeg"
+
+	defaultJPEGQuality = 85
+	defaultMaxTiles    = 4
+)
+
+// ImagePreprocess configures how images are prepared before they are sent
+// to a vision model. It is opt-in: set it on Gollama.ImagePreprocess for
+// every request, or pass it as a ChatOption for a single call.
+//
+// Preprocessed images are decoded and encoded again, which always strips
+// their EXIF metadata. Images in formats the standard library can not
+// decode (PNG, JPEG and GIF are supported) are sent unchanged.
+type ImagePreprocess struct {
+	// MaxDimension downscales images so their longest side is at most this
+	// many pixels. 0 keeps the original size.
+	MaxDimension int
+	// Format is the format images are converted to: ImageFormatPNG or
+	// ImageFormatJPEG. Empty keeps JPEG images as JPEG and converts the
+	// rest to PNG.
+	Format string
+	// JPEGQuality is the quality used when encoding JPEG (default 85).
+	JPEGQuality int
+	// AutoOrient rotates JPEG images as their EXIF orientation says, so the
+	// model sees them upright after the metadata is stripped.
+	AutoOrient bool
+	// TileSize splits images larger than this many pixels on either side
+	// into tiles of at most TileSize x TileSize, sent as separate images in
+	// reading order. 0 disables tiling.
+	TileSize int
+	// MaxTiles limits the number of tiles per image (default 4). Larger
+	// images are downscaled until they fit.
+	MaxTiles int
+}
+
+// SetImagePreprocess enables image preprocessing for every request.
+func (c *Gollama) SetImagePreprocess(preprocess ImagePreprocess) *Gollama {
+	c.ImagePreprocess = &preprocess
+	return c
+}
+
+// imagePreprocessFor returns the preprocessing to use for a request: the
+// per-call option if any, else the Gollama setting.
+func (c *Gollama) imagePreprocessFor(options []ChatOption) *ImagePreprocess {
+	preprocess := c.ImagePreprocess
+	for _, option := range options {
+		if opt, ok := option.(ImagePreprocess); ok {
+			preprocess = &opt
+		}
+	}
+	return preprocess
+}
+
+// preprocessImages applies the preprocessing to every image. Tiling may
+// turn one image into several. A nil preprocess returns the images as they
+// are.
+func preprocessImages(images []PromptImage, preprocess *ImagePreprocess) ([]PromptImage, error) {
+	if preprocess == nil || len(images) == 0 {
+		return images, nil
+	}
+
+	out := make([]PromptImage, 0, len(images))
+	for _, img := range images {
+		processed, err := preprocess.apply(img)
+		if err != nil {
+			return nil, fmt.Errorf("error preprocessing %s: %w", img.name(), err)
+		}
+		out = append(out, processed...)
+	}
+
+	return out, nil
+}
+
+// apply preprocesses a single image.
+func (p ImagePreprocess) apply(src PromptImage) ([]PromptImage, error) {
+	var (
+		img    = src.Image
+		format = ImageFormatPNG
+		data   []byte
+	)
+
+	if img == nil {
+		r, closeFn, err := src.open()
+		if err != nil {
+			return nil, err
+		}
+		data, err = io.ReadAll(r)
+		closeFn()
+		if err != nil {
+			return nil, err
+		}
+
+		var decodedFormat string
+		img, decodedFormat, err = image.Decode(bytes.NewReader(data))
+		if errors.Is(err, image.ErrFormat) {
+			return []PromptImage{{Data: data}}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if decodedFormat == ImageFormatJPEG {
+			format = ImageFormatJPEG
+			if p.AutoOrient {
+				img = applyOrientation(img, jpegOrientation(data))
+			}
+		}
+	}
+
+	if p.Format != "" {
+		format = p.Format
+	}
+	if format != ImageFormatPNG && format != ImageFormatJPEG {
+		return nil, fmt.Errorf("unsupported image format %q", format)
+	}
+
+	var crops []image.Image
+	if p.TileSize > 0 && longestSide(img) > p.TileSize {
+		crops = p.tiles(img)
+	} else {
+		if p.MaxDimension > 0 && longestSide(img) > p.MaxDimension {
+			img = resizeToFit(img, p.MaxDimension)
+		}
+		crops = []image.Image{img}
+	}
+
+	out := make([]PromptImage, 0, len(crops))
+	for _, crop := range crops {
+		encoded, err := p.encode(crop, format)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, PromptImage{Data: encoded})
+	}
+
+	return out, nil
+}
+
+// tiles splits the image into a grid of tiles of at most TileSize pixels
+// per side, downscaling it first if the grid would have more than MaxTiles.
+func (p ImagePreprocess) tiles(img image.Image) []image.Image {
+	maxTiles := p.MaxTiles
+	if maxTiles <= 0 {
+		maxTiles = defaultMaxTiles
+	}
+
+	size := p.TileSize
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	if ceilDiv(w, size)*ceilDiv(h, size) > maxTiles {
+		// Find the largest scale at which the image fits in a grid of at
+		// most maxTiles tiles.
+		scale := 0.0
+		for cols := 1; cols <= maxTiles; cols++ {
+			for rows := 1; cols*rows <= maxTiles; rows++ {
+				s := min(float64(cols*size)/float64(w), float64(rows*size)/float64(h))
+				scale = max(scale, s)
+			}
+		}
+
+		w, h = max(1, int(float64(w)*scale)), max(1, int(float64(h)*scale))
+		img = resizeTo(img, w, h)
+	}
+	cols, rows := ceilDiv(w, size), ceilDiv(h, size)
+
+	b = img.Bounds()
+	tiles := make([]image.Image, 0, cols*rows)
+	for y := 0; y < rows; y++ {
+		for x := 0; x < cols; x++ {
+			r := image.Rect(x*size, y*size, min((x+1)*size, w), min((y+1)*size, h)).Add(b.Min)
+			tile := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+			draw.Draw(tile, tile.Bounds(), img, r.Min, draw.Src)
+			tiles = append(tiles, tile)
+		}
+	}
+
+	return tiles
+}
+
+// encode encodes the image in the given format.
+func (p ImagePreprocess) encode(img image.Image, format string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if format == ImageFormatPNG {
+		err := png.Encode(&buf, img)
+		return buf.Bytes(), err
+	}
+
+	quality := p.JPEGQuality
+	if quality <= 0 || quality > 100 {
+		quality = defaultJPEGQuality
+	}
+
+	// JPEG has no alpha channel, so transparent areas are made white
+	// instead of black.
+	flat := image.NewRGBA(img.Bounds())
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, draw.Over)
+
+	err := jpeg.Encode(&buf, flat, &jpeg.Options{Quality: quality})
+	return buf.Bytes(), err
+}
+
+func longestSide(img image.Image) int {
+	b := img.Bounds()
+	return max(b.Dx(), b.Dy())
+}
+
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
+
+// resizeToFit downscales the image so its longest side is maxDimension,
+// keeping the aspect ratio.
+func resizeToFit(img image.Image, maxDimension int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w >= h {
+		return resizeTo(img, maxDimension, max(1, h*maxDimension/w))
+	}
+	return resizeTo(img, max(1, w*maxDimension/h), maxDimension)
+}
+
+// resizeTo downscales the image to w x h by averaging the source pixels
+// that fall in every destination pixel (a box filter), which gives good
+// results for the large reductions typical for photos.
+func resizeTo(img image.Image, w, h int) *image.RGBA {
+	src := toRGBA(img)
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	for y := 0; y < h; y++ {
+		y0, y1 := y*sh/h, max((y+1)*sh/h, y*sh/h+1)
+		for x := 0; x < w; x++ {
+			x0, x1 := x*sw/w, max((x+1)*sw/w, x*sw/w+1)
+
+			var r, g, b, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				i := src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					r += uint32(src.Pix[i])
+					g += uint32(src.Pix[i+1])
+					b += uint32(src.Pix[i+2])
+					a += uint32(src.Pix[i+3])
+					n++
+					i += 4
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
+
+// toRGBA converts the image to *image.RGBA with its origin at (0, 0).
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Bounds().Min == (image.Point{}) {
+		return rgba
+	}
+
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	return rgba
+}
+
+// applyOrientation transforms the image as described by an EXIF
+// orientation value (1 to 8), so it is displayed upright.
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	src := toRGBA(img)
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // mirrored horizontally
+				sx, sy = w-1-x, y
+			case 3: // rotated 180
+				sx, sy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				sx, sy = x, h-1-y
+			case 5: // transposed
+				sx, sy = y, x
+			case 6: // rotated 90 clockwise
+				sx, sy = y, h-1-x
+			case 7: // transversed
+				sx, sy = w-1-y, h-1-x
+			case 8: // rotated 90 counter-clockwise
+				sx, sy = w-1-y, x
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], src.Pix[src.PixOffset(sx, sy):src.PixOffset(sx, sy)+4])
+		}
+	}
+
+	return dst
+}
+
+// jpegOrientation returns the EXIF orientation of a JPEG file, or 1 if it
+// has none.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	// Walk the segments until the APP1 segment holding the EXIF data.
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if marker == 0xDA || length < 2 || i+2+length > len(data) {
+			return 1 // start of the image data, or a broken file
+		}
+
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+
+		i += 2 + length
+	}
+
+	return 1
+}
+
+// exifOrientation reads the orientation tag from the first IFD of a TIFF
+// structure.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+
+	entries := int(order.Uint16(tiff[ifd:]))
+	for e := 0; e < entries; e++ {
+		entry := ifd + 2 + e*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+
+	return 1
+}
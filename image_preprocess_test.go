@@ -0,0 +1,171 @@
+package gollama
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"testing"
+)
+
+// withOrientation inserts an EXIF segment with the given orientation after
+// the start marker of a JPEG file.
+func withOrientation(jpg []byte, orientation byte) []byte {
+	tiff := []byte{
+		'I', 'I', 0x2A, 0x00, 0x08, 0x00, 0x00, 0x00, // header, IFD at 8
+		0x01, 0x00, // one entry
+		0x12, 0x01, 0x03, 0x00, 0x01, 0x00, 0x00, 0x00, orientation, 0x00, 0x00, 0x00, // orientation, SHORT
+		0x00, 0x00, 0x00, 0x00, // no next IFD
+	}
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	length := len(payload) + 2
+
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, 0xFF, 0xE1, byte(length>>8), byte(length))
+	out = append(out, payload...)
+	return append(out, jpg[2:]...)
+}
+
+func TestImagePreprocess_apply(t *testing.T) {
+	// A 400x200 image, red on the left half and blue on the right one.
+	img := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 400; x++ {
+			c := color.RGBA{255, 0, 0, 255}
+			if x >= 200 {
+				c = color.RGBA{0, 0, 255, 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+
+	var pngData, jpgData bytes.Buffer
+	png.Encode(&pngData, img)
+	jpeg.Encode(&jpgData, img, &jpeg.Options{Quality: 95})
+
+	tests := []struct {
+		name       string
+		preprocess ImagePreprocess
+		image      PromptImage
+		wantCount  int
+		wantMIME   string
+		wantSize   image.Point
+	}{
+		{
+			name:       "Downscale PNG",
+			preprocess: ImagePreprocess{MaxDimension: 100},
+			image:      ImageFromBytes(pngData.Bytes()),
+			wantCount:  1,
+			wantMIME:   "image/png",
+			wantSize:   image.Pt(100, 50),
+		},
+		{
+			name:       "Keep JPEG",
+			preprocess: ImagePreprocess{MaxDimension: 1000},
+			image:      ImageFromBytes(jpgData.Bytes()),
+			wantCount:  1,
+			wantMIME:   "image/jpeg",
+			wantSize:   image.Pt(400, 200),
+		},
+		{
+			name:       "Convert image.Image to JPEG",
+			preprocess: ImagePreprocess{Format: ImageFormatJPEG},
+			image:      ImageFromImage(img),
+			wantCount:  1,
+			wantMIME:   "image/jpeg",
+			wantSize:   image.Pt(400, 200),
+		},
+		{
+			name:       "Apply orientation",
+			preprocess: ImagePreprocess{AutoOrient: true},
+			image:      ImageFromBytes(withOrientation(jpgData.Bytes(), 6)),
+			wantCount:  1,
+			wantMIME:   "image/jpeg",
+			wantSize:   image.Pt(200, 400),
+		},
+		{
+			name:       "Tiles",
+			preprocess: ImagePreprocess{TileSize: 200},
+			image:      ImageFromBytes(pngData.Bytes()),
+			wantCount:  2,
+			wantMIME:   "image/png",
+			wantSize:   image.Pt(200, 200),
+		},
+		{
+			name:       "Tiles limited by MaxTiles",
+			preprocess: ImagePreprocess{TileSize: 100, MaxTiles: 2},
+			image:      ImageFromBytes(pngData.Bytes()),
+			wantCount:  2,
+			wantMIME:   "image/png",
+			wantSize:   image.Pt(100, 100),
+		},
+		{
+			name:       "Unknown format is sent unchanged",
+			preprocess: ImagePreprocess{MaxDimension: 10},
+			image:      ImageFromBytes([]byte("RIFF\x00\x00\x00\x00WEBPVP8 ")),
+			wantCount:  1,
+			wantMIME:   "image/webp",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.preprocess.apply(tt.image)
+			if err != nil {
+				t.Fatalf("ImagePreprocess.apply() error = %v", err)
+			}
+			if len(got) != tt.wantCount {
+				t.Fatalf("ImagePreprocess.apply() returned %d images, want %d", len(got), tt.wantCount)
+			}
+			if mime := http.DetectContentType(got[0].Data); mime != tt.wantMIME {
+				t.Errorf("ImagePreprocess.apply() MIME = %s, want %s", mime, tt.wantMIME)
+			}
+			if tt.wantSize == (image.Point{}) {
+				return
+			}
+			cfg, _, err := image.DecodeConfig(bytes.NewReader(got[0].Data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Width != tt.wantSize.X || cfg.Height != tt.wantSize.Y {
+				t.Errorf("ImagePreprocess.apply() size = %dx%d, want %v", cfg.Width, cfg.Height, tt.wantSize)
+			}
+		})
+	}
+}
+
+func TestApplyOrientation(t *testing.T) {
+	// 2x1 image: red, blue.
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	red, blue := color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 255, 255}
+	img.Set(0, 0, red)
+	img.Set(1, 0, blue)
+
+	tests := []struct {
+		orientation int
+		want        []color.RGBA // pixels in reading order
+	}{
+		{1, []color.RGBA{red, blue}},
+		{2, []color.RGBA{blue, red}},
+		{3, []color.RGBA{blue, red}},
+		{6, []color.RGBA{red, blue}},
+		{8, []color.RGBA{blue, red}},
+	}
+	for _, tt := range tests {
+		got := applyOrientation(img, tt.orientation)
+		b := got.Bounds()
+		pixels := []color.RGBA{}
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				pixels = append(pixels, color.RGBAModel.Convert(got.At(x, y)).(color.RGBA))
+			}
+		}
+		for i := range tt.want {
+			if pixels[i] != tt.want[i] {
+				t.Errorf("applyOrientation(%d) = %v, want %v", tt.orientation, pixels, tt.want)
+				break
+			}
+		}
+	}
+}
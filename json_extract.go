@@ -0,0 +1,607 @@
+package gollama
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// JSONSelect chooses which JSON value DecodeContent decodes when the
+// content has several.
+type JSONSelect int
+
+const (
+	// JSONLast decodes the last JSON value (the default).
+	JSONLast JSONSelect = iota
+	// JSONFirst decodes the first JSON value.
+	JSONFirst
+	// JSONAll decodes every JSON value into a slice.
+	JSONAll
+)
+
+// ExtractJSON returns the JSON objects and arrays found in a text, in
+// order, such as the answer of a model with prose or code fences around
+// its JSON. When code fences hold JSON other than bracketed prose, only the
+// values inside them are returned. Brackets that look like prose, such as the citation "[1]" or
+// an empty "[]", are left out when there are other values.
+//
+// Common mistakes of models are repaired: trailing commas, single-quoted
+// strings, unquoted keys, Python literals (True, False, None) and missing
+// closing brackets at the end of a truncated answer.
+func ExtractJSON(content string) []json.RawMessage {
+	fenced := []json.RawMessage{}
+	for _, block := range fencedBlocks(content) {
+		fenced = append(fenced, scanJSON(block)...)
+	}
+	if fenced = dropTrivial(fenced); len(fenced) > 0 && !isTrivialJSON(fenced[0]) {
+		return fenced
+	}
+
+	return dropTrivial(scanJSON(content))
+}
+
+// fencedBlocks returns the content of the code fences of a text. A fence
+// left open at the end, as in a truncated answer, is included.
+func fencedBlocks(content string) []string {
+	parts := strings.Split(content, "```")
+
+	blocks := []string{}
+	for i := 1; i < len(parts); i += 2 {
+		blocks = append(blocks, parts[i])
+	}
+
+	return blocks
+}
+
+// scanJSON returns the JSON values found in a text, in order.
+func scanJSON(content string) []json.RawMessage {
+	values := []json.RawMessage{}
+
+	for i := 0; i < len(content); i++ {
+		if content[i] != '{' && content[i] != '[' {
+			continue
+		}
+
+		r := jsonRepairer{src: content[i:]}
+		value, n, ok := r.repair()
+		if !ok {
+			continue
+		}
+
+		values = append(values, value)
+		i += n - 1
+	}
+
+	return values
+}
+
+// dropTrivial removes the values that look like bracketed prose, unless
+// there are only such values.
+func dropTrivial(values []json.RawMessage) []json.RawMessage {
+	kept := []json.RawMessage{}
+	for _, value := range values {
+		if !isTrivialJSON(value) {
+			kept = append(kept, value)
+		}
+	}
+
+	if len(kept) == 0 {
+		return values
+	}
+	return kept
+}
+
+// isTrivialJSON reports whether a value is an empty object or array, or an
+// array of a single number like a citation.
+func isTrivialJSON(value json.RawMessage) bool {
+	switch string(value) {
+	case "{}", "[]":
+		return true
+	}
+
+	var items []any
+	if err := json.Unmarshal(value, &items); err != nil || len(items) != 1 {
+		return false
+	}
+	_, ok := items[0].(float64)
+	return ok
+}
+
+// jsonKind returns the first byte of the JSON values v can be decoded
+// from: '{' for structs and maps, '[' for slices and arrays, and 0 when
+// it is not known.
+func jsonKind(v any) byte {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil {
+		return 0
+	}
+
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map:
+		return '{'
+	case reflect.Slice, reflect.Array:
+		return '['
+	}
+	return 0
+}
+
+// selectJSON returns the value to decode among the values found. For
+// JSONFirst and JSONLast, only the values of the given kind ('{' or '[')
+// are considered, if there are any.
+func selectJSON(values []json.RawMessage, selection JSONSelect, kind byte) (json.RawMessage, error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no JSON found in content")
+	}
+
+	if kind != 0 && selection != JSONAll {
+		matching := []json.RawMessage{}
+		for _, value := range values {
+			if value[0] == kind {
+				matching = append(matching, value)
+			}
+		}
+		if len(matching) > 0 {
+			values = matching
+		}
+	}
+
+	switch selection {
+	case JSONFirst:
+		return values[0], nil
+	case JSONAll:
+		all, err := json.Marshal(values)
+		return all, err
+	default:
+		return values[len(values)-1], nil
+	}
+}
+
+// jsonRepairer reads one JSON value at the start of src, repairing it
+// where needed, and writes it as valid JSON.
+type jsonRepairer struct {
+	src string
+	pos int
+	out []byte
+
+	// containers are the open objects and arrays, with what each one
+	// expects next.
+	containers []jsonContainer
+	// pendingComma is a comma that is only written if another value
+	// follows, which drops trailing commas.
+	pendingComma bool
+	// keyStart is the length of out before the key of the current object
+	// member, to drop the member if the input ends before its value.
+	keyStart int
+
+	// elementDepth, when set, is the number of containers around the
+	// elements of an array to record in elements, as the part of out they
+	// take, once they are complete. elementStart is where the current
+	// element starts in out.
+	elementDepth int
+	elements     []jsonSpan
+	elementStart int
+
+	// partial reports that the last token read was cut by the end of the
+	// input, for feed.
+	partial bool
+}
+
+// repairState is the state of a jsonRepairer before a token, restored by
+// feed when the token is cut.
+type repairState struct {
+	pos, outLen, keyStart  int
+	elements, elementStart int
+	containers             []jsonContainer
+	pendingComma           bool
+}
+
+// jsonSpan is a part of the output of a jsonRepairer.
+type jsonSpan struct {
+	start, end int
+}
+
+type jsonContainer struct {
+	close  byte // '}' or ']'
+	expect jsonExpect
+}
+
+type jsonExpect int
+
+const (
+	expectValue jsonExpect = iota
+	expectKey
+	expectColon
+	expectCommaOrEnd
+)
+
+// repair returns the repaired value, the number of bytes of src it used,
+// and whether src starts with something that can be read as JSON.
+func (r *jsonRepairer) repair() (json.RawMessage, int, bool) {
+	for len(r.out) == 0 || len(r.containers) > 0 {
+		r.skipSpace()
+
+		if r.pos >= len(r.src) {
+			if len(r.containers) == 0 {
+				return nil, 0, false
+			}
+			r.finishTruncated()
+			break
+		}
+
+		if !r.step() {
+			return nil, 0, false
+		}
+	}
+
+	if !json.Valid(r.out) {
+		return nil, 0, false
+	}
+
+	return json.RawMessage(r.out), r.pos, true
+}
+
+// feed adds text to the input, as it is streamed, and reads the tokens it
+// completes. A token cut by the end of the input is read again with the
+// next text, so every byte is read about once. It returns false if the
+// input is not JSON.
+func (r *jsonRepairer) feed(text string) bool {
+	// Only the unread input is kept.
+	r.src = r.src[r.pos:] + text
+	r.pos = 0
+
+	for len(r.out) == 0 || len(r.containers) > 0 {
+		r.skipSpace()
+		if r.pos >= len(r.src) {
+			break
+		}
+
+		state := r.save()
+		r.partial = false
+		ok := r.step()
+		if r.partial || (!ok && r.pos >= len(r.src)) {
+			r.restore(state)
+			break
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// snapshot returns the value fed so far, completed like repair completes a
+// truncated input, in a copy of the repairer.
+func (r *jsonRepairer) snapshot() (*jsonRepairer, bool) {
+	s := *r
+	s.out = append([]byte(nil), r.out...)
+	s.containers = append([]jsonContainer(nil), r.containers...)
+	s.elements = r.elements[:len(r.elements):len(r.elements)]
+
+	if _, _, ok := s.repair(); !ok {
+		return nil, false
+	}
+	return &s, true
+}
+
+func (r *jsonRepairer) save() repairState {
+	return repairState{
+		pos:          r.pos,
+		outLen:       len(r.out),
+		keyStart:     r.keyStart,
+		elements:     len(r.elements),
+		elementStart: r.elementStart,
+		containers:   append([]jsonContainer(nil), r.containers...),
+		pendingComma: r.pendingComma,
+	}
+}
+
+func (r *jsonRepairer) restore(s repairState) {
+	r.pos = s.pos
+	r.out = r.out[:s.outLen]
+	r.keyStart = s.keyStart
+	r.containers = s.containers
+	r.pendingComma = s.pendingComma
+	r.elements = r.elements[:s.elements]
+	r.elementStart = s.elementStart
+}
+
+func (r *jsonRepairer) skipSpace() {
+	for r.pos < len(r.src) && strings.IndexByte(" \t\r\n", r.src[r.pos]) >= 0 {
+		r.pos++
+	}
+}
+
+func (r *jsonRepairer) top() *jsonContainer {
+	if len(r.containers) == 0 {
+		return nil
+	}
+	return &r.containers[len(r.containers)-1]
+}
+
+// step reads the next token. It returns false if the input is not JSON.
+func (r *jsonRepairer) step() bool {
+	c := r.src[r.pos]
+	top := r.top()
+
+	expect := expectValue
+	if top != nil {
+		expect = top.expect
+	}
+
+	switch {
+	case c == ',':
+		if top == nil || expect != expectCommaOrEnd {
+			return false
+		}
+		r.pos++
+		r.pendingComma = true
+		if top.close == '}' {
+			top.expect = expectKey
+		} else {
+			top.expect = expectValue
+		}
+		return true
+
+	case c == ':':
+		if expect != expectColon {
+			return false
+		}
+		r.pos++
+		r.out = append(r.out, ':')
+		top.expect = expectValue
+		return true
+
+	case c == '}' || c == ']':
+		if top == nil || top.close != c {
+			return false
+		}
+		switch expect {
+		case expectColon:
+			return false
+		case expectValue:
+			if c == '}' {
+				// A key with a colon but no value.
+				r.out = append(r.out, "null"...)
+			}
+		}
+		r.pos++
+		r.pendingComma = false
+		r.out = append(r.out, c)
+		r.containers = r.containers[:len(r.containers)-1]
+		r.valueDone()
+		return true
+
+	case expect == expectKey:
+		return r.readKey()
+
+	case expect == expectValue:
+		return r.readValue()
+	}
+
+	return false
+}
+
+// beginItem writes the pending comma before a new key or value.
+func (r *jsonRepairer) beginItem() {
+	if r.pendingComma {
+		r.out = append(r.out, ',')
+		r.pendingComma = false
+	}
+}
+
+// valueDone updates the state after a complete value.
+func (r *jsonRepairer) valueDone() {
+	if top := r.top(); top != nil {
+		top.expect = expectCommaOrEnd
+	}
+	if r.inElements() {
+		r.elements = append(r.elements, jsonSpan{r.elementStart, len(r.out)})
+	}
+}
+
+// inElements reports whether the values read now are elements to record.
+func (r *jsonRepairer) inElements() bool {
+	return r.elementDepth > 0 && len(r.containers) == r.elementDepth && r.top().close == ']'
+}
+
+func (r *jsonRepairer) readKey() bool {
+	c := r.src[r.pos]
+	r.keyStart = len(r.out)
+	r.beginItem()
+
+	switch {
+	case c == '"' || c == '\'':
+		if !r.readString() {
+			// The input ends in the key.
+			r.out = r.out[:r.keyStart]
+			r.pos = len(r.src)
+			r.partial = true
+			return true
+		}
+	case isIdentStart(c):
+		word := r.readWord()
+		r.partial = r.pos >= len(r.src)
+		r.out = append(r.out, '"')
+		r.out = append(r.out, word...)
+		r.out = append(r.out, '"')
+	default:
+		return false
+	}
+
+	r.top().expect = expectColon
+	return true
+}
+
+func (r *jsonRepairer) readValue() bool {
+	c := r.src[r.pos]
+	r.beginItem()
+	if r.inElements() {
+		r.elementStart = len(r.out)
+	}
+
+	switch {
+	case c == '{' || c == '[':
+		r.pos++
+		r.out = append(r.out, c)
+		container := jsonContainer{close: '}', expect: expectKey}
+		if c == '[' {
+			container = jsonContainer{close: ']', expect: expectValue}
+		}
+		r.containers = append(r.containers, container)
+		return true
+
+	case c == '"' || c == '\'':
+		if !r.readString() {
+			// Close the string of a truncated input.
+			r.out = append(r.out, '"')
+			r.partial = true
+		}
+
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := r.pos
+		for r.pos < len(r.src) && strings.IndexByte("0123456789.eE+-", r.src[r.pos]) >= 0 {
+			r.pos++
+		}
+		number := r.src[start:r.pos]
+		if r.pos >= len(r.src) {
+			// The number may be cut, e.g. "1." or "2e".
+			number = strings.TrimRight(number, ".eE+-")
+			r.partial = true
+			if number == "" {
+				r.dropMember()
+				return true
+			}
+		}
+		r.out = append(r.out, number...)
+
+	case isIdentStart(c):
+		word := r.readWord()
+		atEnd := r.pos >= len(r.src)
+		literal, ok := jsonLiteral(word, atEnd)
+		if !ok {
+			return false
+		}
+		r.partial = atEnd
+		r.out = append(r.out, literal...)
+
+	default:
+		return false
+	}
+
+	r.valueDone()
+	return true
+}
+
+// dropMember removes the key of an object member whose value is missing.
+func (r *jsonRepairer) dropMember() {
+	if top := r.top(); top != nil && top.close == '}' {
+		r.out = r.out[:r.keyStart]
+	}
+	r.pos = len(r.src)
+}
+
+// readString reads a string quoted with double or single quotes and writes
+// it with double quotes. It returns false if the input ends inside it.
+func (r *jsonRepairer) readString() bool {
+	quote := r.src[r.pos]
+	r.pos++
+	r.out = append(r.out, '"')
+
+	for r.pos < len(r.src) {
+		c := r.src[r.pos]
+		switch {
+		case c == quote:
+			r.pos++
+			r.out = append(r.out, '"')
+			return true
+		case c == '\\':
+			if r.pos+1 >= len(r.src) {
+				r.pos++
+				return false
+			}
+			next := r.src[r.pos+1]
+			if next == '\'' {
+				r.out = append(r.out, '\'')
+			} else {
+				r.out = append(r.out, c, next)
+			}
+			r.pos += 2
+		case c == '"':
+			// A double quote in a single-quoted string.
+			r.out = append(r.out, '\\', '"')
+			r.pos++
+		case c == '\n':
+			r.out = append(r.out, '\\', 'n')
+			r.pos++
+		case c == '\t':
+			r.out = append(r.out, '\\', 't')
+			r.pos++
+		case c == '\r':
+			r.pos++
+		default:
+			r.out = append(r.out, c)
+			r.pos++
+		}
+	}
+
+	return false
+}
+
+func (r *jsonRepairer) readWord() string {
+	start := r.pos
+	for r.pos < len(r.src) && isIdentChar(r.src[r.pos]) {
+		r.pos++
+	}
+	return r.src[start:r.pos]
+}
+
+// finishTruncated completes a value whose input ended before its end:
+// incomplete members are dropped and the open containers are closed.
+func (r *jsonRepairer) finishTruncated() {
+	if top := r.top(); top != nil && top.close == '}' && (top.expect == expectColon || top.expect == expectValue) {
+		r.out = r.out[:r.keyStart]
+	}
+
+	r.pendingComma = false
+	for i := len(r.containers) - 1; i >= 0; i-- {
+		r.out = append(r.out, r.containers[i].close)
+	}
+	r.containers = nil
+}
+
+// jsonLiteral returns the JSON literal for a bare word: true, false and
+// null, and their Python spellings. At the end of a truncated input, a
+// prefix of a literal is completed.
+func jsonLiteral(word string, atEnd bool) (string, bool) {
+	switch word {
+	case "true", "True":
+		return "true", true
+	case "false", "False":
+		return "false", true
+	case "null", "None", "undefined":
+		return "null", true
+	}
+
+	if atEnd && word != "" {
+		for _, literal := range []string{"true", "false", "null"} {
+			if strings.HasPrefix(literal, word) {
+				return literal, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || c == '-' || (c >= '0' && c <= '9')
+}
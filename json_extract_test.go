@@ -0,0 +1,181 @@
+package gollama
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "No JSON",
+			content: "hello {world} [see note]",
+			want:    []string{},
+		},
+		{
+			name:    "Top-level array",
+			content: "Here you go: [1, 2, 3]",
+			want:    []string{`[1,2,3]`},
+		},
+		{
+			name:    "JSON followed by prose",
+			content: `{"a": 1} I hope this helps!`,
+			want:    []string{`{"a":1}`},
+		},
+		{
+			name:    "Brackets in strings",
+			content: "```json\n{\"text\": \"a } and a \\\" [\", \"n\": 2}\n```",
+			want:    []string{`{"text":"a } and a \" [","n":2}`},
+		},
+		{
+			name:    "Several values",
+			content: "first {\"a\": 1} then [{\"b\": 2}]",
+			want:    []string{`{"a":1}`, `[{"b":2}]`},
+		},
+		{
+			name:    "Citation after fenced JSON",
+			content: "```json {\"id\":1} ``` Source: [1]",
+			want:    []string{`{"id":1}`},
+		},
+		{
+			name:    "Prose before fenced JSON",
+			content: "Using {name} and [x]:\n```json\n[{\"id\":1}]\n```",
+			want:    []string{`[{"id":1}]`},
+		},
+		{
+			name:    "Only trivial values in fences",
+			content: "```go\nx := []int{}\n```\nResult: {\"a\":1}",
+			want:    []string{`{"a":1}`},
+		},
+		{
+			name:    "Citation after JSON",
+			content: `{"id": 1} Source: [1], see also []`,
+			want:    []string{`{"id":1}`},
+		},
+		{
+			name:    "Only a citation-like value",
+			content: `The answer is [42]`,
+			want:    []string{`[42]`},
+		},
+		{
+			name:    "Trailing commas",
+			content: `{"a": [1, 2,], "b": 3,}`,
+			want:    []string{`{"a":[1,2],"b":3}`},
+		},
+		{
+			name:    "Single quotes and unquoted keys",
+			content: `{name: 'O\'Brien', "say": 'a "quote"'}`,
+			want:    []string{`{"name":"O'Brien","say":"a \"quote\""}`},
+		},
+		{
+			name:    "Python literals",
+			content: `{'ok': True, 'err': None}`,
+			want:    []string{`{"ok":true,"err":null}`},
+		},
+		{
+			name:    "Truncated",
+			content: `{"items": [{"name": "a"}, {"name": "b", "pri`,
+			want:    []string{`{"items":[{"name":"a"},{"name":"b"}]}`},
+		},
+		{
+			name:    "Truncated in a value",
+			content: `{"done": tr`,
+			want:    []string{`{"done":true}`},
+		},
+		{
+			name:    "Truncated in a string",
+			content: `["one", "tw`,
+			want:    []string{`["one","tw"]`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, v := range ExtractJSON(tt.content) {
+				got = append(got, string(v))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractJSON() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChatOuput_DecodeContent_Select(t *testing.T) {
+	o := ChatOuput{Content: "Paris: {\"city\": \"Paris\"}, Rome: {\"city\": \"Rome\"}"}
+
+	type city struct {
+		City string `json:"city"`
+	}
+
+	var first, last city
+	var all []city
+
+	if err := o.DecodeContent(&first, JSONFirst); err != nil || first.City != "Paris" {
+		t.Errorf("DecodeContent(JSONFirst) = %+v, %v", first, err)
+	}
+	if err := o.DecodeContent(&last); err != nil || last.City != "Rome" {
+		t.Errorf("DecodeContent() = %+v, %v", last, err)
+	}
+	if err := o.DecodeContent(&all, JSONAll); err != nil || !reflect.DeepEqual(all, []city{{"Paris"}, {"Rome"}}) {
+		t.Errorf("DecodeContent(JSONAll) = %+v, %v", all, err)
+	}
+
+	// A fence of code does not hide the JSON after it.
+	code := ChatOuput{Content: "```go\nx := []int{}\n```\nResult: {\"city\": \"Lima\"}"}
+
+	var lima city
+	if err := code.DecodeContent(&lima); err != nil || lima.City != "Lima" {
+		t.Errorf("DecodeContent() = %+v, %v", lima, err)
+	}
+
+	// Values of another kind than the target are skipped.
+	mixed := ChatOuput{Content: "{\"city\": \"Oslo\"} has these scores: [3, 4]"}
+
+	var oslo city
+	var scores []int
+
+	if err := mixed.DecodeContent(&oslo); err != nil || oslo.City != "Oslo" {
+		t.Errorf("DecodeContent() = %+v, %v", oslo, err)
+	}
+	if err := mixed.DecodeContent(&scores, JSONFirst); err != nil || !reflect.DeepEqual(scores, []int{3, 4}) {
+		t.Errorf("DecodeContent(JSONFirst) = %v, %v", scores, err)
+	}
+}
+
+func TestJSONRepairer_feed(t *testing.T) {
+	content := `{"items": [{"name": 'Pa\'ris', "n": -12.5e3, ok: True}, {"name": "Rome", "tags": ["a", "b",], "none": None}], "done": false}`
+
+	// Fed one byte at a time, the snapshots match the repair of every
+	// prefix.
+	r := jsonRepairer{}
+	for i := 1; i <= len(content); i++ {
+		if !r.feed(content[i-1 : i]) {
+			t.Fatalf("feed() failed after %q", content[:i])
+		}
+
+		want := jsonRepairer{src: content[:i]}
+		wantValue, _, wantOK := want.repair()
+
+		got, ok := r.snapshot()
+		if ok != wantOK || (ok && string(got.out) != string(wantValue)) {
+			t.Fatalf("snapshot after %q = %s (%v), want %s (%v)", content[:i], got.out, ok, wantValue, wantOK)
+		}
+	}
+
+	if len(r.containers) != 0 || string(r.out) != `{"items":[{"name":"Pa'ris","n":-12.5e3,"ok":true},{"name":"Rome","tags":["a","b"],"none":null}],"done":false}` {
+		t.Errorf("feed() = %s", r.out)
+	}
+
+	if (&jsonRepairer{}).feed(`{"a": 1}}`) != true {
+		t.Errorf("feed() should stop after the value")
+	}
+	if (&jsonRepairer{}).feed(`{"a" 1}`) {
+		t.Errorf("feed() of invalid JSON should fail")
+	}
+}
@@ -0,0 +1,150 @@
+package gollama
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	maxTopLogprobs     = 20  // the most alternatives Ollama returns per token
+	maxCandidateTokens = 256 // safety limit for ScoreCandidates
+)
+
+// TotalLogprob returns the sum of the log-probabilities of the generated
+// tokens, i.e. the log-probability of the whole response.
+func (o ChatOuput) TotalLogprob() float64 {
+	return totalLogprob(o.Logprobs)
+}
+
+// TotalLogprob returns the sum of the log-probabilities of the generated
+// tokens, i.e. the log-probability of the whole response.
+func (o GenerateOutput) TotalLogprob() float64 {
+	return totalLogprob(o.Logprobs)
+}
+
+func totalLogprob(logprobs []TokenLogprob) float64 {
+	total := 0.0
+	for _, l := range logprobs {
+		total += l.Logprob
+	}
+	return total
+}
+
+// ScoreCandidates scores a fixed set of candidate answers to a prompt, and
+// returns them ranked from the most to the least likely by their total
+// log-probability. It is useful for classification, e.g. with candidates
+// like "positive", "negative" and "neutral".
+//
+// Ollama can not score a given text directly, so every candidate is forced
+// token by token as a prefilled assistant answer: each step asks for one
+// token with its top alternatives, and takes the alternative that continues
+// the candidate. This costs one request per token of each candidate, so
+// keep candidates short.
+//
+// Options are the same as for Chat; Options and Logprobs are overridden.
+func (c *Gollama) ScoreCandidates(ctx context.Context, prompt string, candidates []string, options ...ChatOption) ([]CandidateScore, error) {
+	messages, options := c.promptMessages(prompt, options)
+	options = append(options,
+		Options{NumPredict: Ptr(1), Temperature: Ptr(0.0)},
+		Logprobs{TopN: maxTopLogprobs},
+	)
+
+	scores := make([]CandidateScore, 0, len(candidates))
+	for _, candidate := range candidates {
+		score, err := c.scoreCandidate(ctx, messages, candidate, options)
+		if err != nil {
+			return nil, err
+		}
+		scores = append(scores, score)
+	}
+
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].Logprob > scores[j].Logprob
+	})
+
+	return scores, nil
+}
+
+func (c *Gollama) scoreCandidate(ctx context.Context, messages []Message, candidate string, options []ChatOption) (CandidateScore, error) {
+	score := CandidateScore{
+		Candidate: candidate,
+		Tokens:    []TokenLogprob{},
+		Exact:     true,
+	}
+
+	prefix := ""
+	for step := 0; prefix != candidate; step++ {
+		if step == maxCandidateTokens {
+			return CandidateScore{}, fmt.Errorf("candidate %q is too long to score", candidate)
+		}
+
+		forced := messages
+		if prefix != "" {
+			forced = append(append([]Message{}, messages...), Message{
+				Role:    RoleAssistant,
+				Content: prefix,
+			})
+		}
+
+		out, err := c.chat(ctx, forced, options)
+		if err != nil {
+			return CandidateScore{}, err
+		}
+
+		if len(out.Logprobs) == 0 {
+			return CandidateScore{}, fmt.Errorf("the server did not return logprobs")
+		}
+
+		remaining := candidate[len(prefix):]
+		alternatives := out.Logprobs[0].TopLogprobs
+		if len(alternatives) == 0 {
+			alternatives = out.Logprobs[:1]
+		}
+
+		best, ok := longestMatchingToken(alternatives, remaining)
+		if !ok {
+			// The next token is not among the alternatives, so its
+			// logprob is at most the lowest one returned.
+			lowest := alternatives[0]
+			for _, a := range alternatives {
+				if a.Logprob < lowest.Logprob {
+					lowest = a
+				}
+			}
+
+			score.Logprob += lowest.Logprob
+			score.Tokens = append(score.Tokens, TokenLogprob{Token: remaining, Logprob: lowest.Logprob})
+			score.Exact = false
+			break
+		}
+
+		score.Logprob += best.Logprob
+		score.Tokens = append(score.Tokens, best)
+		prefix += best.Token
+	}
+
+	return score, nil
+}
+
+// longestMatchingToken returns the longest non-empty token among the
+// alternatives that is a prefix of text.
+func longestMatchingToken(alternatives []TokenLogprob, text string) (TokenLogprob, bool) {
+	var (
+		best  TokenLogprob
+		found bool
+	)
+
+	for _, a := range alternatives {
+		if a.Token == "" || !strings.HasPrefix(text, a.Token) {
+			continue
+		}
+		if !found || len(a.Token) > len(best.Token) {
+			best = a
+			found = true
+		}
+	}
+
+	return best, found
+}
@@ -0,0 +1,79 @@
+package gollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"net/http"
+	"os"
+	"testing"
+)
+
+func TestGollama_ScoreCandidates(t *testing.T) {
+	// A fake model that knows how to continue each prefix.
+	next := map[string][]TokenLogprob{
+		"":    {{Token: "pos", Logprob: -0.1}, {Token: "neg", Logprob: -2.5}, {Token: "p", Logprob: -3}},
+		"pos": {{Token: "itive", Logprob: -0.01}, {Token: "ter", Logprob: -5}},
+		"neg": {{Token: "ative", Logprob: -0.02}},
+	}
+
+	road, _ := os.ReadFile("./test/road.png")
+	wantImage := base64.StdEncoding.EncodeToString(road)
+
+	c := newTestServer(t, "test", func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if !req.Logprobs || req.TopLogprobs != maxTopLogprobs || *req.Options.NumPredict != 1 {
+			t.Errorf("unexpected request %+v", req)
+		}
+		// The image is sent with every token request.
+		if images := req.Messages[0].Images; len(images) != 1 || images[0] != wantImage {
+			t.Errorf("the image was not sent with the request for %q", req.Messages[len(req.Messages)-1].Content)
+		}
+
+		prefix := ""
+		if last := req.Messages[len(req.Messages)-1]; last.Role == RoleAssistant {
+			prefix = last.Content
+		}
+
+		top := next[prefix]
+		json.NewEncoder(w).Encode(chatResponse{
+			Model:    "test",
+			Message:  messageResponse{Role: "assistant", Content: top[0].Token},
+			Done:     true,
+			Logprobs: []TokenLogprob{{Token: top[0].Token, Logprob: top[0].Logprob, TopLogprobs: top}},
+		})
+	})
+
+	got, err := c.ScoreCandidates(context.Background(), "Sentiment of 'I love it'?", []string{"negative", "neutral", "positive"}, ImageFromReader(bytes.NewReader(road)))
+	if err != nil {
+		t.Fatalf("Gollama.ScoreCandidates() error = %v", err)
+	}
+
+	want := []struct {
+		candidate string
+		logprob   float64
+		exact     bool
+	}{
+		{"positive", -0.11, true},
+		{"negative", -2.52, true},
+		{"neutral", -3, false},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Gollama.ScoreCandidates() = %+v", got)
+	}
+	for i, w := range want {
+		if got[i].Candidate != w.candidate || math.Abs(got[i].Logprob-w.logprob) > 1e-9 || got[i].Exact != w.exact {
+			t.Errorf("Gollama.ScoreCandidates()[%d] = %+v, want %+v", i, got[i], w)
+		}
+	}
+}
+
+func TestChatOuput_TotalLogprob(t *testing.T) {
+	o := ChatOuput{Logprobs: []TokenLogprob{{Token: "a", Logprob: -0.5}, {Token: "b", Logprob: -0.25}}}
+	if got := o.TotalLogprob(); got != -0.75 {
+		t.Errorf("ChatOuput.TotalLogprob() = %v, want -0.75", got)
+	}
+}
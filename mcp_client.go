@@ -140,7 +140,9 @@ func (c *McpClient) readLoop() {
 	}
 }
 
-func (c *McpClient) sendRequest(method string, params interface{}) (*JsonRpcResponse, error) {
+// sendRequest sends a request and waits for its response, or until ctx is
+// done.
+func (c *McpClient) sendRequest(ctx context.Context, method string, params interface{}) (*JsonRpcResponse, error) {
 	id := atomic.AddInt64(&c.seq, 1)
 	idStr := fmt.Sprintf("%d", id)
 	idRaw := json.RawMessage(idStr)
@@ -164,11 +166,18 @@ func (c *McpClient) sendRequest(method string, params interface{}) (*JsonRpcResp
 
 	c.logger("MCP > %s", string(reqBytes))
 	if _, err := c.stdin.Write(append(reqBytes, '\n')); err != nil {
+		c.removePending(idStr)
 		return nil, err
 	}
 
 	// Wait for response
-	resp := <-ch
+	var resp *JsonRpcResponse
+	select {
+	case resp = <-ch:
+	case <-ctx.Done():
+		c.removePending(idStr)
+		return nil, ctx.Err()
+	}
 	if resp.Error != nil {
 		return nil, fmt.Errorf("MCP error (%d): %s", resp.Error.Code, resp.Error.Message)
 	}
@@ -176,6 +185,12 @@ func (c *McpClient) sendRequest(method string, params interface{}) (*JsonRpcResp
 	return resp, nil
 }
 
+func (c *McpClient) removePending(id string) {
+	c.pendingMu.Lock()
+	delete(c.pending, id)
+	c.pendingMu.Unlock()
+}
+
 func (c *McpClient) sendNotification(method string, params interface{}) error {
 	req := JsonRpcRequest{
 		JSONRPC: "2.0",
@@ -203,7 +218,7 @@ func (c *McpClient) initialize(ctx context.Context) error {
 		},
 	}
 
-	resp, err := c.sendRequest("initialize", params)
+	resp, err := c.sendRequest(ctx, "initialize", params)
 	if err != nil {
 		return err
 	}
@@ -219,7 +234,7 @@ func (c *McpClient) initialize(ctx context.Context) error {
 
 // ListTools returns the list of tools available on the MCP server converted to Gollama Tools.
 func (c *McpClient) ListTools() ([]Tool, error) {
-	resp, err := c.sendRequest("tools/list", map[string]interface{}{})
+	resp, err := c.sendRequest(context.Background(), "tools/list", map[string]interface{}{})
 	if err != nil {
 		return nil, err
 	}
@@ -246,12 +261,18 @@ func (c *McpClient) ListTools() ([]Tool, error) {
 
 // CallTool executes a tool on the MCP server.
 func (c *McpClient) CallTool(name string, arguments map[string]any) (string, error) {
+	return c.CallToolContext(context.Background(), name, arguments)
+}
+
+// CallToolContext executes a tool on the MCP server, and stops waiting for
+// the result when ctx is done.
+func (c *McpClient) CallToolContext(ctx context.Context, name string, arguments map[string]any) (string, error) {
 	params := McpCallToolParams{
 		Name:      name,
 		Arguments: arguments,
 	}
 
-	resp, err := c.sendRequest("tools/call", params)
+	resp, err := c.sendRequest(ctx, "tools/call", params)
 	if err != nil {
 		return "", err
 	}
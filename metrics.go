@@ -0,0 +1,68 @@
+package gollama
+
+import "time"
+
+// doneReasonLength is the done_reason Ollama reports when the answer was
+// cut off by num_predict or the context length.
+const doneReasonLength = "length"
+
+// TokensPerSecond returns the generation speed of the response.
+func (o ChatOuput) TokensPerSecond() float64 {
+	return tokensPerSecond(o.ResponseTokens, o.EvalDuration)
+}
+
+// PromptTokensPerSecond returns the speed at which the prompt was processed.
+func (o ChatOuput) PromptTokensPerSecond() float64 {
+	return tokensPerSecond(o.PromptTokens, o.PromptEvalDuration)
+}
+
+// TimeToFirstToken returns the time the server took before it started
+// generating: loading the model plus processing the prompt.
+func (o ChatOuput) TimeToFirstToken() time.Duration {
+	return o.LoadDuration + o.PromptEvalDuration
+}
+
+// Truncated reports whether the response was cut off because it reached
+// num_predict or the context length.
+func (o ChatOuput) Truncated() bool {
+	return o.DoneReason == doneReasonLength
+}
+
+// TokensPerSecond returns the generation speed of the response.
+func (o GenerateOutput) TokensPerSecond() float64 {
+	return tokensPerSecond(o.ResponseTokens, o.EvalDuration)
+}
+
+// PromptTokensPerSecond returns the speed at which the prompt was processed.
+func (o GenerateOutput) PromptTokensPerSecond() float64 {
+	return tokensPerSecond(o.PromptTokens, o.PromptEvalDuration)
+}
+
+// TimeToFirstToken returns the time the server took before it started
+// generating: loading the model plus processing the prompt.
+func (o GenerateOutput) TimeToFirstToken() time.Duration {
+	return o.LoadDuration + o.PromptEvalDuration
+}
+
+// Truncated reports whether the response was cut off because it reached
+// num_predict or the context length.
+func (o GenerateOutput) Truncated() bool {
+	return o.DoneReason == doneReasonLength
+}
+
+func tokensPerSecond(tokens int, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(tokens) / d.Seconds()
+}
+
+// parseTime parses the timestamps returned by Ollama, returning the zero
+// time if they are missing or invalid.
+func parseTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -0,0 +1,42 @@
+package gollama
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestChatOuput_Metrics(t *testing.T) {
+	c := newTestServer(t, "test", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"model":"test","created_at":"2024-07-22T20:33:28.123648Z","message":{"role":"assistant","content":"Hello"},"done":true,"done_reason":"length","total_duration":5000000000,"load_duration":1000000000,"prompt_eval_count":20,"prompt_eval_duration":500000000,"eval_count":100,"eval_duration":2000000000}`)
+	})
+
+	got, err := c.Chat(context.Background(), "hi")
+	if err != nil {
+		t.Fatalf("Gollama.Chat() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{name: "Model", got: got.Model, want: "test"},
+		{name: "CreatedAt", got: got.CreatedAt, want: time.Date(2024, 7, 22, 20, 33, 28, 123648000, time.UTC)},
+		{name: "TotalDuration", got: got.TotalDuration, want: 5 * time.Second},
+		{name: "TokensPerSecond", got: got.TokensPerSecond(), want: 50.0},
+		{name: "PromptTokensPerSecond", got: got.PromptTokensPerSecond(), want: 40.0},
+		{name: "TimeToFirstToken", got: got.TimeToFirstToken(), want: 1500 * time.Millisecond},
+		{name: "Truncated", got: got.Truncated(), want: true},
+		{name: "No duration", got: ChatOuput{ResponseTokens: 10}.TokensPerSecond(), want: 0.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if fmt.Sprint(tt.got) != fmt.Sprint(tt.want) {
+				t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+			}
+		})
+	}
+}
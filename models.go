@@ -179,3 +179,65 @@ func (c *Gollama) GetModels(ctx context.Context) ([]string, error) {
 
 	return res, nil
 }
+
+// PreloadModel loads models into memory without generating anything, so the
+// first real request does not have to wait for it.
+//
+// The model stays loaded for the KeepAlive duration of the Gollama object,
+// or the server default if it is unset.
+//
+// If no model is specified, the model name set in the Gollama object is used.
+func (c *Gollama) PreloadModel(ctx context.Context, model ...string) error {
+	return c.setModelResidency(ctx, c.keepAliveRequest(nil), model)
+}
+
+// UnloadModel evicts models from memory right away.
+//
+// If no model is specified, the model name set in the Gollama object is used.
+func (c *Gollama) UnloadModel(ctx context.Context, model ...string) error {
+	return c.setModelResidency(ctx, "0s", model)
+}
+
+// setModelResidency sends an empty generate request, which only loads or
+// unloads the model depending on keepAlive.
+func (c *Gollama) setModelResidency(ctx context.Context, keepAlive string, model []string) error {
+	if len(model) == 0 {
+		model = []string{c.ModelName}
+	}
+
+	for _, m := range model {
+		req := struct {
+			Model     string `json:"model"`
+			KeepAlive string `json:"keep_alive,omitempty"`
+		}{
+			Model:     m,
+			KeepAlive: keepAlive,
+		}
+
+		var resp generateResponse
+		err := c.apiPost(ctx, "/api/generate", &resp, req)
+		if err != nil {
+			return err
+		}
+
+		if resp.Error != "" {
+			return fmt.Errorf("ollama: %s", resp.Error)
+		}
+	}
+
+	return nil
+}
+
+// RunningModels lists the models currently loaded in memory, with their
+// size, VRAM usage, context length and when they will be unloaded.
+//
+// The function will return an error if the request fails.
+func (c *Gollama) RunningModels(ctx context.Context) ([]RunningModel, error) {
+	var resp psResponse
+	err := c.apiGet(ctx, "/api/ps", &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Models, nil
+}
@@ -2,7 +2,12 @@ package gollama
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestGollama_ListModels(t *testing.T) {
@@ -186,3 +191,101 @@ func TestGollama_GetModels(t *testing.T) {
 		})
 	}
 }
+
+func TestGollama_ModelResidency(t *testing.T) {
+	tests := []struct {
+		name          string
+		c             *Gollama
+		call          func(c *Gollama) error
+		wantPath      string
+		wantKeepAlive string
+	}{
+		{
+			name:          "Preload with default keep alive",
+			c:             New("llama3.2"),
+			call:          func(c *Gollama) error { return c.PreloadModel(context.Background()) },
+			wantPath:      "/api/generate",
+			wantKeepAlive: "",
+		},
+		{
+			name:          "Preload forever",
+			c:             New("llama3.2").SetKeepAlive(-1),
+			call:          func(c *Gollama) error { return c.PreloadModel(context.Background()) },
+			wantPath:      "/api/generate",
+			wantKeepAlive: "-1ns",
+		},
+		{
+			name:          "Unload",
+			c:             New("llama3.2").SetKeepAlive(time.Hour),
+			call:          func(c *Gollama) error { return c.UnloadModel(context.Background()) },
+			wantPath:      "/api/generate",
+			wantKeepAlive: "0s",
+		},
+		{
+			name: "Chat with keep alive option",
+			c:    New("llama3.2").SetKeepAlive(time.Hour),
+			call: func(c *Gollama) error {
+				_, err := c.Chat(context.Background(), "hi", KeepAlive(10*time.Minute))
+				return err
+			},
+			wantPath:      "/api/chat",
+			wantKeepAlive: "10m0s",
+		},
+		{
+			name: "Embedding with default keep alive",
+			c:    New("llama3.2").SetKeepAlive(time.Hour),
+			call: func(c *Gollama) error {
+				_, err := c.Embedding(context.Background(), "hi")
+				return err
+			},
+			wantPath:      "/api/embeddings",
+			wantKeepAlive: "1h0m0s",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req struct {
+					Model     string `json:"model"`
+					KeepAlive string `json:"keep_alive"`
+				}
+				json.NewDecoder(r.Body).Decode(&req)
+				if r.URL.Path != tt.wantPath || req.KeepAlive != tt.wantKeepAlive || req.Model != "llama3.2" {
+					t.Errorf("request %s %+v, want %s with keep_alive %q", r.URL.Path, req, tt.wantPath, tt.wantKeepAlive)
+				}
+				fmt.Fprint(w, `{"model":"llama3.2","done":true}`)
+			}))
+			defer srv.Close()
+
+			tt.c.ServerAddr = srv.URL
+			if err := tt.call(tt.c); err != nil {
+				t.Errorf("error = %v", err)
+			}
+		})
+	}
+}
+
+func TestGollama_RunningModels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/ps" {
+			t.Errorf("request to %s, want /api/ps", r.URL.Path)
+		}
+		fmt.Fprint(w, `{"models":[{"name":"llama3.2:latest","model":"llama3.2:latest","size":3400000000,"size_vram":3000000000,"expires_at":"2024-06-04T14:38:31.83753-07:00","context_length":4096,"details":{"family":"llama"}}]}`)
+	}))
+	defer srv.Close()
+
+	c := New("llama3.2")
+	c.ServerAddr = srv.URL
+
+	got, err := c.RunningModels(context.Background())
+	if err != nil {
+		t.Fatalf("Gollama.RunningModels() error = %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("Gollama.RunningModels() = %+v", got)
+	}
+	m := got[0]
+	if m.Name != "llama3.2:latest" || m.Size != 3400000000 || m.SizeVRAM != 3000000000 || m.ContextLength != 4096 || m.ExpiresAt.IsZero() || m.Details.Family != "llama" {
+		t.Errorf("Gollama.RunningModels() = %+v", m)
+	}
+}
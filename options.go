@@ -0,0 +1,97 @@
+package gollama
+
+import "reflect"
+
+// Options are the model parameters sent to Ollama with a request.
+//
+// Fields left nil are not sent, so the model defaults apply. Use Ptr to set
+// them:
+//
+//	g.SetOptions(gollama.Options{
+//		Temperature: gollama.Ptr(0.7),
+//		NumPredict:  gollama.Ptr(256),
+//		Stop:        []string{"\n\n"},
+//	})
+//
+// Options set on the Gollama object are the defaults for every request, and
+// can be overridden per call by passing an Options value as a ChatOption.
+// Only the fields set in the per-call Options replace the defaults.
+type Options struct {
+	// Sampling
+	Seed             *int     `json:"seed,omitempty"`
+	Temperature      *float64 `json:"temperature,omitempty"`
+	TopK             *int     `json:"top_k,omitempty"`
+	TopP             *float64 `json:"top_p,omitempty"`
+	MinP             *float64 `json:"min_p,omitempty"`
+	TypicalP         *float64 `json:"typical_p,omitempty"`
+	RepeatLastN      *int     `json:"repeat_last_n,omitempty"`
+	RepeatPenalty    *float64 `json:"repeat_penalty,omitempty"`
+	PresencePenalty  *float64 `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty"`
+	Mirostat         *int     `json:"mirostat,omitempty"`
+	MirostatTau      *float64 `json:"mirostat_tau,omitempty"`
+	MirostatEta      *float64 `json:"mirostat_eta,omitempty"`
+	PenalizeNewline  *bool    `json:"penalize_newline,omitempty"`
+	NumPredict       *int     `json:"num_predict,omitempty"`
+	NumKeep          *int     `json:"num_keep,omitempty"`
+	Stop             []string `json:"stop,omitempty"`
+
+	// Runtime
+	NumCtx    *int64 `json:"num_ctx,omitempty"`
+	NumBatch  *int   `json:"num_batch,omitempty"`
+	NumGPU    *int   `json:"num_gpu,omitempty"`
+	MainGPU   *int   `json:"main_gpu,omitempty"`
+	UseMMap   *bool  `json:"use_mmap,omitempty"`
+	NumThread *int   `json:"num_thread,omitempty"`
+}
+
+// Ptr returns a pointer to v. It is a shorthand to fill Options fields.
+func Ptr[T any](v T) *T {
+	return &v
+}
+
+// Merge returns a copy of o with every field set in other replacing the one
+// in o.
+func (o Options) Merge(other Options) Options {
+	merged := o
+	dst := reflect.ValueOf(&merged).Elem()
+	src := reflect.ValueOf(other)
+
+	for i := 0; i < src.NumField(); i++ {
+		field := src.Field(i)
+		if !field.IsZero() {
+			dst.Field(i).Set(field)
+		}
+	}
+
+	return merged
+}
+
+// SetOptions sets the default model parameters for every request.
+func (c *Gollama) SetOptions(options Options) *Gollama {
+	c.Options = options
+	return c
+}
+
+// newOptionsRequest builds the model parameters sent with a request: the
+// legacy Gollama settings, overridden by Gollama.Options, overridden by the
+// per-call options.
+func (c *Gollama) newOptionsRequest(call Options) Options {
+	var temperature float64
+	if c.SeedOrNegative < 0 {
+		temperature = c.TemperatureIfNegativeSeed
+	}
+
+	options := Options{
+		Seed:        Ptr(c.SeedOrNegative),
+		Temperature: Ptr(temperature),
+		TopK:        Ptr(c.TopK),
+		TopP:        Ptr(c.TopP),
+	}
+
+	if c.ContextLength != 0 {
+		options.NumCtx = Ptr(c.ContextLength)
+	}
+
+	return options.Merge(c.Options).Merge(call)
+}
@@ -0,0 +1,49 @@
+package gollama
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGollama_newOptionsRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		c    *Gollama
+		call Options
+		want string
+	}{
+		{
+			name: "Legacy fixed seed",
+			c:    New("llama3.2"),
+			want: `{"seed":256,"temperature":0,"top_k":0,"top_p":0}`,
+		},
+		{
+			name: "Legacy random seed",
+			c:    New("llama3.2").SetRandomSeed().SetContextLength(4096),
+			want: `{"seed":-1,"temperature":0.8,"top_k":0,"top_p":0,"num_ctx":4096}`,
+		},
+		{
+			name: "Temperature with fixed seed",
+			c:    New("llama3.2").SetSeed(42).SetTemperature(0.5),
+			want: `{"seed":42,"temperature":0.5,"top_k":0,"top_p":0}`,
+		},
+		{
+			name: "Defaults and per-call override",
+			c: New("llama3.2").SetOptions(Options{
+				NumPredict: Ptr(100),
+				Stop:       []string{"\n"},
+				Mirostat:   Ptr(2),
+			}),
+			call: Options{NumPredict: Ptr(10), TopK: Ptr(5), MinP: Ptr(0.05), NumThread: Ptr(4)},
+			want: `{"seed":256,"temperature":0,"top_k":5,"top_p":0,"min_p":0.05,"mirostat":2,"num_predict":10,"stop":["\n"],"num_thread":4}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := json.Marshal(tt.c.newOptionsRequest(tt.call))
+			if string(got) != tt.want {
+				t.Errorf("Gollama.newOptionsRequest() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
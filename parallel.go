@@ -0,0 +1,87 @@
+package gollama
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CallTools runs the tool calls with executor, at most limit at once (all
+// of them if limit is 0 or less), and returns their results in the order
+// of the calls. The error of a call is in its result and does not stop the
+// others.
+func CallTools(ctx context.Context, executor ToolExecutor, calls []ToolCall, limit int) []ToolResult {
+	if limit <= 0 {
+		limit = len(calls)
+	}
+
+	results := make([]ToolResult, len(calls))
+	runConcurrently(ctx, len(calls), limit, false, func(ctx context.Context, i int) (bool, error) {
+		call := calls[i]
+		start := time.Now()
+		result, err := executor.CallToolContext(ctx, call.Function.Name, call.Function.Arguments)
+		results[i] = ToolResult{
+			Call:      call,
+			Arguments: call.Function.Arguments,
+			Result:    result,
+			Err:       err,
+			Duration:  time.Since(start),
+		}
+		return err != nil, nil
+	})
+
+	return results
+}
+
+// runConcurrently calls fn for the indexes 0 to n-1, with at most limit
+// calls running at once. Calls start in order.
+//
+// fn reports whether its call failed, and returns an error if the failure
+// is fatal. A fatal error, or a failure when cancelOnError is set, cancels
+// the context of the other calls. The first fatal error is returned once
+// every call is over.
+func runConcurrently(ctx context.Context, n, limit int, cancelOnError bool, fn func(ctx context.Context, i int) (bool, error)) error {
+	if limit <= 0 {
+		limit = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		fatal error
+		sem   = make(chan struct{}, limit)
+	)
+
+	for i := 0; i < n; i++ {
+		sem <- struct{}{}
+		wg.Add(1)
+
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			failed, err := fn(ctx, i)
+			if err != nil {
+				mu.Lock()
+				if fatal == nil {
+					fatal = err
+				}
+				mu.Unlock()
+				cancel()
+				return
+			}
+			if failed && cancelOnError {
+				cancel()
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	return fatal
+}
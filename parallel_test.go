@@ -0,0 +1,136 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCallTools(t *testing.T) {
+	type cityArgs struct {
+		City string `json:"city"`
+	}
+
+	var (
+		mu             sync.Mutex
+		running, peak  int
+		releaseRunning = make(chan struct{})
+		release        sync.Once
+	)
+
+	registry := NewToolRegistry()
+	RegisterTool(registry, "weather", "Get the weather", func(ctx context.Context, args cityArgs) (string, error) {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		if running == 2 {
+			release.Do(func() { close(releaseRunning) })
+		}
+		mu.Unlock()
+
+		select {
+		case <-releaseRunning:
+		case <-time.After(time.Second):
+		}
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+
+		if args.City == "Atlantis" {
+			return "", errors.New("unknown city")
+		}
+		return "sunny in " + args.City, nil
+	})
+
+	calls := []ToolCall{}
+	for _, city := range []string{"Paris", "Atlantis", "Rome"} {
+		calls = append(calls, ToolCall{Function: ToolCallFunction{Name: "weather", Arguments: map[string]any{"city": city}}})
+	}
+
+	results := CallTools(context.Background(), registry, calls, 2)
+
+	if peak != 2 {
+		t.Errorf("peak concurrency = %d, want 2", peak)
+	}
+
+	want := []string{"sunny in Paris", "", "sunny in Rome"}
+	for i, res := range results {
+		if res.Result != want[i] || (i == 1) != (res.Err != nil) {
+			t.Errorf("results[%d] = %q, %v, want %q", i, res.Result, res.Err, want[i])
+		}
+	}
+}
+
+func TestAgent_ParallelTools(t *testing.T) {
+	registry := NewToolRegistry()
+	RegisterTool(registry, "fail", "Fails", func(ctx context.Context, args struct{}) (string, error) {
+		return "", errors.New("boom")
+	})
+	RegisterTool(registry, "wait", "Waits until cancelled", func(ctx context.Context, args struct{}) (string, error) {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+			return "finished", nil
+		}
+	})
+
+	tests := []struct {
+		name          string
+		cancelOnError bool
+		want          []string
+	}{
+		{
+			name: "Errors do not stop the others",
+			want: []string{"error: boom", "finished"},
+		},
+		{
+			name:          "Cancel on error",
+			cancelOnError: true,
+			want:          []string{"error: boom", "error: " + context.Canceled.Error()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			c := newTestServer(t, "test", func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				resp := chatResponse{Model: "test", Message: messageResponse{Role: "assistant", Content: "Done"}, Done: true}
+				if requests == 1 {
+					resp.Message = messageResponse{Role: "assistant", ToolCalls: []ToolCall{
+						{Function: ToolCallFunction{Name: "wait"}},
+						{Function: ToolCallFunction{Name: "fail"}},
+					}}
+				}
+				json.NewEncoder(w).Encode(resp)
+			})
+
+			agent := c.NewAgent(registry)
+			agent.MaxParallelTools = 2
+			agent.CancelOnError = tt.cancelOnError
+
+			result, err := agent.Run(context.Background(), "Go")
+			if err != nil {
+				t.Fatalf("Agent.Run() error = %v", err)
+			}
+
+			got := []string{}
+			for _, m := range result.Messages {
+				if m.Role == RoleTool {
+					got = append(got, fmt.Sprintf("%s=%s", m.ToolName, m.Content))
+				}
+			}
+			want := []string{"wait=" + tt.want[1], "fail=" + tt.want[0]}
+			if fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("tool messages = %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,344 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrSessionNotFound is returned by a SessionStore when the session does not
+// exist or has expired.
+var ErrSessionNotFound = errors.New("session not found")
+
+// Session is a stored conversation history.
+type Session struct {
+	ID        string    `json:"id"`
+	Messages  []Message `json:"messages"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SessionStore persists conversation histories by session ID.
+//
+// Load must return ErrSessionNotFound for unknown or expired sessions.
+type SessionStore interface {
+	Load(ctx context.Context, id string) (*Session, error)
+	Save(ctx context.Context, session *Session) error
+	List(ctx context.Context) ([]string, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// LoadConversation restores the conversation stored under id in the store.
+//
+// If there is no such session, a new conversation is started, just like
+// NewConversation.
+func (c *Gollama) LoadConversation(ctx context.Context, store SessionStore, id string) (*Conversation, error) {
+	session, err := store.Load(ctx, id)
+	if errors.Is(err, ErrSessionNotFound) {
+		return c.NewConversation(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cv := c.NewConversation()
+	cv.SetMessages(session.Messages)
+	cv.createdAt = session.CreatedAt
+	return cv, nil
+}
+
+// Save stores the conversation history in the store under id.
+func (cv *Conversation) Save(ctx context.Context, store SessionStore, id string) error {
+	cv.mu.Lock()
+	createdAt := cv.createdAt
+	cv.mu.Unlock()
+
+	session := &Session{
+		ID:        id,
+		Messages:  cv.Messages(),
+		CreatedAt: createdAt,
+	}
+
+	if err := store.Save(ctx, session); err != nil {
+		return err
+	}
+
+	cv.mu.Lock()
+	cv.createdAt = session.CreatedAt
+	cv.mu.Unlock()
+	return nil
+}
+
+// MemorySessionStore keeps sessions in memory. It is useful for tests and
+// for single-process servers that do not need to survive restarts.
+type MemorySessionStore struct {
+	ttl      time.Duration
+	sessions map[string]Session
+	mu       sync.Mutex
+	now      func() time.Time
+}
+
+// NewMemorySessionStore creates an in-memory session store.
+//
+// Sessions that were not saved for longer than ttl are expired. A ttl of 0
+// keeps sessions forever.
+func NewMemorySessionStore(ttl time.Duration) *MemorySessionStore {
+	return &MemorySessionStore{
+		ttl:      ttl,
+		sessions: make(map[string]Session),
+		now:      time.Now,
+	}
+}
+
+// Load returns the session with the given id.
+func (s *MemorySessionStore) Load(ctx context.Context, id string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+
+	if isExpired(session, s.ttl, s.now()) {
+		delete(s.sessions, id)
+		return nil, ErrSessionNotFound
+	}
+
+	session.Messages = append([]Message{}, session.Messages...)
+	return &session, nil
+}
+
+// Save stores the session, replacing any previous one with the same id.
+//
+// UpdatedAt is set to the current time, and CreatedAt too if it is unset.
+func (s *MemorySessionStore) Save(ctx context.Context, session *Session) error {
+	if session.ID == "" {
+		return fmt.Errorf("session id is empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	touchSession(session, s.now())
+
+	stored := *session
+	stored.Messages = append([]Message{}, session.Messages...)
+	s.sessions[session.ID] = stored
+	return nil
+}
+
+// List returns the ids of all the sessions that have not expired, sorted.
+func (s *MemorySessionStore) List(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	ids := []string{}
+	for id, session := range s.sessions {
+		if isExpired(session, s.ttl, now) {
+			delete(s.sessions, id)
+			continue
+		}
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// Delete removes the session with the given id. Deleting a session that
+// does not exist is not an error.
+func (s *MemorySessionStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, id)
+	return nil
+}
+
+// FileSessionStore keeps every session as a JSON file in a directory, so
+// histories survive process restarts.
+type FileSessionStore struct {
+	dir string
+	ttl time.Duration
+	mu  sync.Mutex
+	now func() time.Time
+}
+
+// NewFileSessionStore creates a session store that saves sessions in dir,
+// creating the directory if needed.
+//
+// Sessions that were not saved for longer than ttl are expired and their
+// files removed. A ttl of 0 keeps sessions forever.
+func NewFileSessionStore(dir string, ttl time.Duration) (*FileSessionStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &FileSessionStore{
+		dir: dir,
+		ttl: ttl,
+		now: time.Now,
+	}, nil
+}
+
+// Load reads the session with the given id from its file.
+func (s *FileSessionStore) Load(ctx context.Context, id string) (*Session, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := readSessionFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if isExpired(*session, s.ttl, s.now()) {
+		os.Remove(path)
+		return nil, ErrSessionNotFound
+	}
+
+	return session, nil
+}
+
+// Save writes the session to its file, replacing any previous one.
+//
+// UpdatedAt is set to the current time, and CreatedAt too if it is unset.
+func (s *FileSessionStore) Save(ctx context.Context, session *Session) error {
+	path, err := s.path(session.ID)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	touchSession(session, s.now())
+
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so a crash never leaves a
+	// half-written session behind.
+	tmp, err := os.CreateTemp(s.dir, ".session-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// List returns the ids of all the sessions that have not expired, sorted.
+func (s *FileSessionStore) List(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	ids := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || filepath.Ext(name) != ".json" {
+			continue
+		}
+
+		path := filepath.Join(s.dir, name)
+		session, err := readSessionFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if isExpired(*session, s.ttl, now) {
+			os.Remove(path)
+			continue
+		}
+
+		ids = append(ids, strings.TrimSuffix(name, ".json"))
+	}
+
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// Delete removes the file of the session with the given id. Deleting a
+// session that does not exist is not an error.
+func (s *FileSessionStore) Delete(ctx context.Context, id string) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path returns the file used for the session id, rejecting ids that could
+// escape the store directory.
+func (s *FileSessionStore) path(id string) (string, error) {
+	if id == "" || strings.HasPrefix(id, ".") || strings.ContainsAny(id, `/\:`) {
+		return "", fmt.Errorf("invalid session id %q", id)
+	}
+
+	return filepath.Join(s.dir, id+".json"), nil
+}
+
+func readSessionFile(path string) (*Session, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("error decoding session %s: %w", path, err)
+	}
+
+	return &session, nil
+}
+
+func touchSession(session *Session, now time.Time) {
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = now
+	}
+	session.UpdatedAt = now
+}
+
+func isExpired(session Session, ttl time.Duration, now time.Time) bool {
+	return ttl > 0 && now.Sub(session.UpdatedAt) > ttl
+}
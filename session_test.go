@@ -0,0 +1,162 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSessionStore(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	fileStore, err := NewFileSessionStore(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileStore.now = clock
+
+	memoryStore := NewMemorySessionStore(time.Hour)
+	memoryStore.now = clock
+
+	tests := []struct {
+		name  string
+		store SessionStore
+	}{
+		{name: "Memory", store: memoryStore},
+		{name: "File", store: fileStore},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			messages := []Message{
+				{Role: RoleUser, Content: "what is this?", Images: []PromptImage{{Filename: "./test/road.png"}}},
+				{Role: RoleAssistant, ToolCalls: []ToolCall{{Function: ToolCallFunction{Name: "look", Arguments: map[string]any{"zoom": 2.0}}}}},
+				{Role: RoleTool, Content: "a llama"},
+			}
+
+			if err := tt.store.Save(ctx, &Session{ID: "a", Messages: messages}); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+			if err := tt.store.Save(ctx, &Session{ID: "b"}); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+
+			got, err := tt.store.Load(ctx, "a")
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if !reflect.DeepEqual(got.Messages, messages) {
+				t.Errorf("Load() messages = %+v, want %+v", got.Messages, messages)
+			}
+			if !got.CreatedAt.Equal(now) || !got.UpdatedAt.Equal(now) {
+				t.Errorf("Load() times = %v %v, want %v", got.CreatedAt, got.UpdatedAt, now)
+			}
+
+			ids, _ := tt.store.List(ctx)
+			if !reflect.DeepEqual(ids, []string{"a", "b"}) {
+				t.Errorf("List() = %v", ids)
+			}
+
+			if err := tt.store.Delete(ctx, "b"); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if _, err := tt.store.Load(ctx, "b"); !errors.Is(err, ErrSessionNotFound) {
+				t.Errorf("Load() after Delete() error = %v, want ErrSessionNotFound", err)
+			}
+
+			now = now.Add(2 * time.Hour)
+			if _, err := tt.store.Load(ctx, "a"); !errors.Is(err, ErrSessionNotFound) {
+				t.Errorf("Load() of expired session error = %v, want ErrSessionNotFound", err)
+			}
+			if ids, _ := tt.store.List(ctx); len(ids) != 0 {
+				t.Errorf("List() after expiry = %v", ids)
+			}
+		})
+	}
+}
+
+func TestFileSessionStore_InvalidID(t *testing.T) {
+	store, err := NewFileSessionStore(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"", "../escape", "a/b", ".hidden"} {
+		if err := store.Save(context.Background(), &Session{ID: id}); err == nil {
+			t.Errorf("Save() with id %q expected an error", id)
+		}
+	}
+}
+
+func TestGollama_LoadConversation(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemorySessionStore(0)
+	c := New("test")
+
+	cv, err := c.LoadConversation(ctx, store, "user-1")
+	if err != nil {
+		t.Fatalf("LoadConversation() error = %v", err)
+	}
+	cv.AddUser("hello")
+	cv.AddAssistant("hi")
+	if err := cv.Save(ctx, store, "user-1"); err != nil {
+		t.Fatalf("Conversation.Save() error = %v", err)
+	}
+
+	restored, err := c.LoadConversation(ctx, store, "user-1")
+	if err != nil {
+		t.Fatalf("LoadConversation() error = %v", err)
+	}
+	if !reflect.DeepEqual(restored.Messages(), cv.Messages()) {
+		t.Errorf("LoadConversation() = %+v, want %+v", restored.Messages(), cv.Messages())
+	}
+}
+
+func TestConversation_SaveDecodedImage(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFileSessionStore(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sent []string
+	c := newTestServer(t, "test", func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		sent = req.Messages[0].Images
+		json.NewEncoder(w).Encode(chatResponse{
+			Model:   "test",
+			Message: messageResponse{Role: "assistant", Content: "A red dot."},
+			Done:    true,
+		})
+	})
+
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, color.RGBA{255, 0, 0, 255})
+
+	cv := c.NewConversation()
+	cv.AddUser("what is this?", ImageFromImage(img))
+	if err := cv.Save(ctx, store, "a"); err != nil {
+		t.Fatalf("Conversation.Save() error = %v", err)
+	}
+
+	restored, err := c.LoadConversation(ctx, store, "a")
+	if err != nil {
+		t.Fatalf("LoadConversation() error = %v", err)
+	}
+	if _, err := restored.Continue(ctx); err != nil {
+		t.Fatalf("Conversation.Continue() of the restored conversation error = %v", err)
+	}
+	if len(sent) != 1 || sent[0] == "" {
+		t.Errorf("the restored image was not sent: %q", sent)
+	}
+}
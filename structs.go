@@ -4,108 +4,610 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
-func (o ChatOuput) DecodeContent(v interface{}) error {
-	if o.Content == "" {
-		return fmt.Errorf("content is empty")
+// DecodeContent decodes the JSON found in the content into v. The JSON may
+// be surrounded by text or code fences, and common mistakes of models are
+// repaired, see ExtractJSON.
+//
+// When the content has several JSON values, the last one is decoded, or
+// the one chosen by selection. Only objects are considered when v is a
+// struct or a map, and only arrays when it is a slice, if there are any.
+// With JSONAll, v must be a slice.
+func (o ChatOuput) DecodeContent(v interface{}, selection ...JSONSelect) error {
+	sel := JSONLast
+	if len(selection) > 0 {
+		sel = selection[0]
 	}
 
-	blocks := strings.Split(o.Content, "```")
+	data, err := o.jsonContent(sel, jsonKind(v))
+	if err != nil {
+		return err
+	}
 
-	var lastJSON string
-	for _, block := range blocks {
-		block = strings.TrimPrefix(block, "json")
-		block = strings.TrimSpace(block)
-		if strings.HasPrefix(block, "{") && strings.HasSuffix(block, "}") {
-			lastJSON = block
-		}
+	err = json.Unmarshal(data, v)
+	if err != nil {
+		return fmt.Errorf("error decoding JSON: %w", err)
 	}
 
-	if lastJSON == "" {
-		return fmt.Errorf("no JSON found in content")
+	return nil
+}
+
+// jsonContent returns the JSON found in the content, preferring values of
+// the given kind, see selectJSON.
+func (o ChatOuput) jsonContent(selection JSONSelect, kind byte) ([]byte, error) {
+	if o.Content == "" {
+		return nil, fmt.Errorf("content is empty")
 	}
 
-	err := json.Unmarshal([]byte(lastJSON), v)
+	// The reasoning of thinking models may contain JSON-like drafts.
+	_, content := splitThinking(o.Content, false)
+
+	return selectJSON(ExtractJSON(content), selection, kind)
+}
+
+// ValidateContent checks the JSON found in the content against the format,
+// like StructuredFormat.Validate.
+func (o ChatOuput) ValidateContent(format StructuredFormat) error {
+	data, err := o.jsonContent(JSONLast, '{')
 	if err != nil {
-		return fmt.Errorf("error decoding JSON: %w", err)
+		return err
 	}
 
-	return nil
+	return format.Validate(data)
 }
 
+// StructToStructuredFormat returns the JSON schema of a struct, to use as
+// the format of a Chat or as the parameters of a tool. See
+// NewStructuredFormat for the supported types and tags.
+//
+// If the struct can not be described, the error is kept in the format and
+// returned by Chat and Generate when the format is used.
 func StructToStructuredFormat(s interface{}) StructuredFormat {
-	structValue := reflect.ValueOf(s)
-	structType := structValue.Type()
+	format, err := NewStructuredFormat(s)
+	if err != nil {
+		return StructuredFormat{err: err}
+	}
+	return format
+}
 
-	properties := make(map[string]FormatProperty)
-	required := make([]string, 0)
+// NewStructuredFormat returns the JSON schema of a struct, or of the struct
+// a pointer points to.
+//
+// Nested structs, slices, arrays, maps, pointers (nullable), time.Time (a
+// date-time string) and embedded structs are supported. Fields are named by
+// their json tag, and skipped if it is "-" or with the tag ignored:"true".
+// These tags describe a field:
+//   - required:"true" makes it required. It can not be used with the
+//     omitempty or omitzero options of the json tag.
+//   - description:"..." describes it.
+//   - enum:"a,b,c" lists the allowed values.
+//   - min:"1" and max:"10" limit numbers.
+//   - pattern:"^[a-z]+$" is a regular expression strings must match.
+//   - default:"..." is its default value.
+//   - examples:"a,b" lists example values.
+func NewStructuredFormat(v any) (StructuredFormat, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct || t == timeType {
+		return StructuredFormat{}, fmt.Errorf("structured format needs a struct, got %T", v)
+	}
 
-	for i := 0; i < structType.NumField(); i++ {
-		field := structType.Field(i)
+	property, err := schemaForType(t, map[reflect.Type]bool{})
+	if err != nil {
+		return StructuredFormat{}, err
+	}
 
-		strType, strItems, err := fieldTypeToGollamaType(field.Type)
+	return StructuredFormat{
+		Type:       "object",
+		Properties: property.Properties,
+		Required:   property.Required,
+	}, nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaForType returns the schema of a Go type. parents holds the structs
+// being described, to reject recursive types.
+func schemaForType(t reflect.Type, parents map[reflect.Type]bool) (FormatProperty, error) {
+	if t.Kind() == reflect.Pointer {
+		property, err := schemaForType(t.Elem(), parents)
+		property.Nullable = true
+		return property, err
+	}
+
+	if t == timeType {
+		return FormatProperty{Type: "string", Format: "date-time"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return FormatProperty{Type: "string"}, nil
+	case reflect.Bool:
+		return FormatProperty{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return FormatProperty{Type: "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return FormatProperty{Type: "number"}, nil
+	case reflect.Interface:
+		// Any JSON value.
+		return FormatProperty{}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			// Byte slices are encoded as a base64 string, byte arrays
+			// as arrays of numbers.
+			return FormatProperty{Type: "string"}, nil
+		}
+		items, err := schemaForType(t.Elem(), parents)
+		if err != nil {
+			return FormatProperty{}, err
+		}
+		return FormatProperty{Type: "array", Items: items.item()}, nil
+	case reflect.Map:
+		switch t.Key().Kind() {
+		case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		default:
+			return FormatProperty{}, fmt.Errorf("unsupported map key type: %s", t.Key())
+		}
+		values, err := schemaForType(t.Elem(), parents)
 		if err != nil {
-			return StructuredFormat{}
+			return FormatProperty{}, err
 		}
+		return FormatProperty{Type: "object", AdditionalProperties: &values}, nil
+	case reflect.Struct:
+		if parents[t] {
+			return FormatProperty{}, fmt.Errorf("recursive type %s is not supported", t)
+		}
+		parents[t] = true
+		defer delete(parents, t)
+
+		property := FormatProperty{Type: "object", Properties: map[string]FormatProperty{}}
+		if err := addStructFields(&property, t, parents); err != nil {
+			return FormatProperty{}, err
+		}
+		return property, nil
+	}
+
+	return FormatProperty{}, fmt.Errorf("unsupported field type: %s", t)
+}
+
+// addStructFields adds the fields of a struct to the properties of an
+// object. The fields of embedded structs are added as if they were fields
+// of the struct, like encoding/json does.
+func addStructFields(object *FormatProperty, t reflect.Type, parents map[reflect.Type]bool) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
 
 		if field.Tag.Get("ignored") == "true" {
 			continue
 		}
 
-		fieldName := field.Name
-		if field.Tag.Get("json") != "" {
-			fieldName = field.Tag.Get("json")
+		name, omitempty, skip := jsonFieldName(field)
+		if skip {
+			continue
 		}
 
-		property := FormatProperty{
-			Type:        strType,
-			Description: field.Tag.Get("description"),
+		if field.Anonymous && !hasJSONName(field) {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct && embedded != timeType {
+				if err := addStructFields(object, embedded, parents); err != nil {
+					return err
+				}
+				continue
+			}
 		}
 
-		if strItems != "" {
-			property.Items = ItemProperty{
-				Type: strItems,
-			}
+		if !field.IsExported() {
+			continue
+		}
+
+		property, err := schemaForType(field.Type, parents)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+
+		if err := applySchemaTags(&property, field); err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
 		}
 
+		object.Properties[name] = property
+
 		if field.Tag.Get("required") == "true" {
-			required = append(required, fieldName)
+			if omitempty {
+				return fmt.Errorf("field %s: required:\"true\" conflicts with omitempty", field.Name)
+			}
+			object.Required = append(object.Required, name)
 		}
+	}
 
-		properties[fieldName] = property
+	return nil
+}
+
+// jsonFieldName returns the name of a field in JSON, whether it has the
+// omitempty option, and whether it is skipped.
+func jsonFieldName(field reflect.StructField) (string, bool, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
 	}
 
-	return StructuredFormat{
-		Type:       "object",
-		Properties: properties,
-		Required:   required,
+	name, options, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
 	}
+
+	omitempty := false
+	for _, option := range strings.Split(options, ",") {
+		if option == "omitempty" || option == "omitzero" {
+			omitempty = true
+		}
+	}
+
+	return name, omitempty, false
 }
 
-func fieldTypeToGollamaType(fieldType reflect.Type) (string, string, error) {
-	switch fieldType.Kind() {
-	case reflect.String:
-		return "string", "", nil
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return "integer", "", nil
-	case reflect.Float32, reflect.Float64:
-		return "number", "", nil
-	case reflect.Bool:
-		return "boolean", "", nil
+func hasJSONName(field reflect.StructField) bool {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	return name != ""
+}
+
+// applySchemaTags sets the description, enum, min, max, pattern, default
+// and examples of a property from the tags of its field. On arrays, enum,
+// min, max and pattern constrain the items.
+func applySchemaTags(property *FormatProperty, field reflect.StructField) error {
+	property.Description = field.Tag.Get("description")
+
+	constrained := schemaConstraints{
+		typ:     property.Type,
+		enum:    &property.Enum,
+		minimum: &property.Minimum,
+		maximum: &property.Maximum,
+		pattern: &property.Pattern,
+	}
+	if property.Type == "array" {
+		constrained = schemaConstraints{
+			typ:     property.Items.Type,
+			enum:    &property.Items.Enum,
+			minimum: &property.Items.Minimum,
+			maximum: &property.Items.Maximum,
+			pattern: &property.Items.Pattern,
+		}
+	}
+
+	if enum, ok := field.Tag.Lookup("enum"); ok {
+		*constrained.enum = splitTagList(enum)
+	}
+
+	numeric := constrained.typ == "integer" || constrained.typ == "number"
+	for _, bound := range []struct {
+		tag string
+		dst **float64
+	}{
+		{"min", constrained.minimum},
+		{"max", constrained.maximum},
+	} {
+		value, ok := field.Tag.Lookup(bound.tag)
+		if !ok {
+			continue
+		}
+		if !numeric {
+			return fmt.Errorf("%s tag on a non-numeric field", bound.tag)
+		}
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s tag %q", bound.tag, value)
+		}
+		*bound.dst = &f
+	}
+
+	if pattern, ok := field.Tag.Lookup("pattern"); ok {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid pattern tag: %w", err)
+		}
+		*constrained.pattern = pattern
+	}
+
+	if def, ok := field.Tag.Lookup("default"); ok {
+		value, err := parseTagValue(def, property.Type)
+		if err != nil {
+			return fmt.Errorf("invalid default tag: %w", err)
+		}
+		property.Default = value
+	}
+
+	if examples, ok := field.Tag.Lookup("examples"); ok {
+		for _, example := range splitTagList(examples) {
+			value, err := parseTagValue(example, property.Type)
+			if err != nil {
+				return fmt.Errorf("invalid examples tag: %w", err)
+			}
+			property.Examples = append(property.Examples, value)
+		}
+	}
+
+	return nil
+}
+
+// schemaConstraints points to the keywords of a schema that the enum,
+// min, max and pattern tags set.
+type schemaConstraints struct {
+	typ              string
+	enum             *[]string
+	minimum, maximum **float64
+	pattern          *string
+}
+
+// splitTagList splits a comma-separated tag value.
+func splitTagList(tag string) []string {
+	values := []string{}
+	for _, value := range strings.Split(tag, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// parseTagValue parses a tag value as a value of the JSON schema type t.
+func parseTagValue(value, t string) (any, error) {
+	switch t {
+	case "integer", "number":
+		return strconv.ParseFloat(value, 64)
+	case "boolean":
+		return strconv.ParseBool(value)
+	case "string", "":
+		return value, nil
+	}
+
+	var decoded any
+	err := json.Unmarshal([]byte(value), &decoded)
+	return decoded, err
+}
+
+// item returns the property as the schema of array items.
+func (p FormatProperty) item() ItemProperty {
+	item := ItemProperty{
+		Type:                 p.Type,
+		Description:          p.Description,
+		Nullable:             p.Nullable,
+		Format:               p.Format,
+		Properties:           p.Properties,
+		Enum:                 p.Enum,
+		Required:             p.Required,
+		AdditionalProperties: p.AdditionalProperties,
+		Minimum:              p.Minimum,
+		Maximum:              p.Maximum,
+		Pattern:              p.Pattern,
+		Default:              p.Default,
+		Examples:             p.Examples,
+	}
+	if p.Items.Type != "" || p.Items.Properties != nil {
+		items := p.Items
+		item.Items = &items
+	}
+	return item
+}
+
+// schemaJSON is the JSON encoding of FormatProperty and ItemProperty.
+type schemaJSON struct {
+	Type                 any                       `json:"type,omitempty"`
+	Description          string                    `json:"description,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Enum                 []any                     `json:"enum,omitempty"`
+	Items                *ItemProperty             `json:"items,omitempty"`
+	Properties           map[string]FormatProperty `json:"properties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	AdditionalProperties json.RawMessage           `json:"additionalProperties,omitempty"`
+	Minimum              *float64                  `json:"minimum,omitempty"`
+	Maximum              *float64                  `json:"maximum,omitempty"`
+	Pattern              string                    `json:"pattern,omitempty"`
+	Default              any                       `json:"default,omitempty"`
+	Examples             []any                     `json:"examples,omitempty"`
+}
+
+func (p FormatProperty) MarshalJSON() ([]byte, error) {
+	s := schemaJSON{
+		Type:        encodeSchemaType(p.Type, p.Nullable),
+		Description: p.Description,
+		Format:      p.Format,
+		Enum:        encodeEnum(p.Enum, p.Type),
+		Properties:  p.Properties,
+		Required:    p.Required,
+		Minimum:     p.Minimum,
+		Maximum:     p.Maximum,
+		Pattern:     p.Pattern,
+		Default:     p.Default,
+		Examples:    p.Examples,
+	}
+	if p.Items.Type != "" || p.Items.Properties != nil {
+		s.Items = &p.Items
+	}
+	if err := s.setAdditionalProperties(p.AdditionalProperties); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(s)
+}
+
+func (p *FormatProperty) UnmarshalJSON(data []byte) error {
+	var s schemaJSON
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	*p = FormatProperty{
+		Description: s.Description,
+		Format:      s.Format,
+		Enum:        decodeEnum(s.Enum),
+		Properties:  s.Properties,
+		Required:    s.Required,
+		Minimum:     s.Minimum,
+		Maximum:     s.Maximum,
+		Pattern:     s.Pattern,
+		Default:     s.Default,
+		Examples:    s.Examples,
+	}
+	p.Type, p.Nullable = decodeSchemaType(s.Type)
+	if s.Items != nil {
+		p.Items = *s.Items
+	}
+	p.AdditionalProperties = s.additionalProperties()
+
+	return nil
+}
+
+func (p ItemProperty) MarshalJSON() ([]byte, error) {
+	s := schemaJSON{
+		Type:        encodeSchemaType(p.Type, p.Nullable),
+		Description: p.Description,
+		Format:      p.Format,
+		Enum:        encodeEnum(p.Enum, p.Type),
+		Items:       p.Items,
+		Properties:  p.Properties,
+		Required:    p.Required,
+		Minimum:     p.Minimum,
+		Maximum:     p.Maximum,
+		Pattern:     p.Pattern,
+		Default:     p.Default,
+		Examples:    p.Examples,
+	}
+	if err := s.setAdditionalProperties(p.AdditionalProperties); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(s)
+}
+
+func (p *ItemProperty) UnmarshalJSON(data []byte) error {
+	var s schemaJSON
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	*p = ItemProperty{
+		Description: s.Description,
+		Format:      s.Format,
+		Enum:        decodeEnum(s.Enum),
+		Items:       s.Items,
+		Properties:  s.Properties,
+		Required:    s.Required,
+		Minimum:     s.Minimum,
+		Maximum:     s.Maximum,
+		Pattern:     s.Pattern,
+		Default:     s.Default,
+		Examples:    s.Examples,
+	}
+	p.Type, p.Nullable = decodeSchemaType(s.Type)
+	p.AdditionalProperties = s.additionalProperties()
+
+	return nil
+}
+
+func (s *schemaJSON) setAdditionalProperties(p *FormatProperty) error {
+	if p == nil {
+		return nil
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	s.AdditionalProperties = data
+	return nil
+}
+
+// additionalProperties returns the schema of additionalProperties. The
+// boolean form is ignored.
+func (s schemaJSON) additionalProperties() *FormatProperty {
+	if len(s.AdditionalProperties) == 0 || s.AdditionalProperties[0] != '{' {
+		return nil
+	}
+	var p FormatProperty
+	if err := json.Unmarshal(s.AdditionalProperties, &p); err != nil {
+		return nil
+	}
+	return &p
+}
+
+// encodeSchemaType returns the value of the type keyword: the type, or
+// the type and "null" if nullable.
+func encodeSchemaType(t string, nullable bool) any {
+	switch {
+	case t == "":
+		return nil
+	case nullable:
+		return []string{t, "null"}
 	default:
-		if fieldType == reflect.TypeOf([]int{}) || fieldType == reflect.TypeOf([]int8{}) ||
-			fieldType == reflect.TypeOf([]int16{}) || fieldType == reflect.TypeOf([]int32{}) ||
-			fieldType == reflect.TypeOf([]int64{}) {
-			return "array", "integer", nil
-		} else if fieldType == reflect.TypeOf([]float32{}) || fieldType == reflect.TypeOf([]float64{}) {
-			return "array", "number", nil
-		} else if fieldType == reflect.TypeOf([]string{}) {
-			return "array", "string", nil
-		} else if fieldType == reflect.TypeOf([]bool{}) {
-			return "array", "boolean", nil
-		}
-		return "string", "", fmt.Errorf("unsupported field type: %s", fieldType.String())
+		return t
+	}
+}
+
+// decodeSchemaType returns the type of a type keyword, and whether it
+// allows null.
+func decodeSchemaType(v any) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, false
+	case []any:
+		t, nullable := "", false
+		for _, item := range v {
+			s, _ := item.(string)
+			if s == "null" {
+				nullable = true
+			} else if t == "" {
+				t = s
+			}
+		}
+		return t, nullable
+	}
+	return "", false
+}
+
+// encodeEnum returns the enum values, as numbers for numeric types.
+func encodeEnum(enum []string, t string) []any {
+	if len(enum) == 0 {
+		return nil
+	}
+
+	values := make([]any, 0, len(enum))
+	for _, e := range enum {
+		if t == "integer" || t == "number" {
+			if f, err := strconv.ParseFloat(e, 64); err == nil {
+				values = append(values, f)
+				continue
+			}
+		}
+		values = append(values, e)
+	}
+	return values
+}
+
+// decodeEnum returns the enum values as strings.
+func decodeEnum(values []any) []string {
+	if len(values) == 0 {
+		return nil
+	}
+
+	enum := make([]string, 0, len(values))
+	for _, v := range values {
+		switch v := v.(type) {
+		case string:
+			enum = append(enum, v)
+		case nil:
+			continue
+		default:
+			enum = append(enum, jsonString(v))
+		}
 	}
+	return enum
 }
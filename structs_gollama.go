@@ -1,5 +1,11 @@
 package gollama
 
+import (
+	"image"
+	"io"
+	"time"
+)
+
 // Models
 
 type ModelInfo struct {
@@ -7,6 +13,25 @@ type ModelInfo struct {
 	Size  int    `json:"size"`
 }
 
+// RunningModel is a model currently loaded in memory by the server.
+type RunningModel struct {
+	Name    string `json:"name"`
+	Model   string `json:"model"`
+	Size    int64  `json:"size"`
+	Digest  string `json:"digest"`
+	Details struct {
+		ParentModel       string   `json:"parent_model"`
+		Format            string   `json:"format"`
+		Family            string   `json:"family"`
+		Families          []string `json:"families"`
+		ParameterSize     string   `json:"parameter_size"`
+		QuantizationLevel string   `json:"quantization_level"`
+	} `json:"details"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	SizeVRAM      int64     `json:"size_vram"`
+	ContextLength int       `json:"context_length"`
+}
+
 // ModelDetails
 
 type ModelDetails struct {
@@ -57,29 +82,72 @@ type ModelDetails struct {
 
 // Format structs
 
+// PromptImage is an image passed as vision input. Set exactly one source:
+// a file, encoded bytes, a reader of encoded bytes, or a decoded image.
+// See ImageFromFile, ImageFromBytes, ImageFromReader and ImageFromImage.
 type PromptImage struct {
-	Filename string `json:"filename"`
+	Filename string      `json:"filename,omitempty"`
+	Data     []byte      `json:"data,omitempty"`
+	Reader   io.Reader   `json:"-"`
+	Image    image.Image `json:"-"`
+
+	// err is the error of reading Reader or Image, reported when the
+	// image is sent.
+	err error
 }
 
+// ItemProperty is the schema of the items of an array.
 type ItemProperty struct {
-	Type       string                    `json:"type"`
-	Properties map[string]FormatProperty `json:"properties,omitempty"`
-	Enum       []string                  `json:"enum,omitempty"`
-	Required   []string                  `json:"required,omitempty"`
-	Items      *ItemProperty             `json:"items,omitempty"`
+	Type                 string                    `json:"type"`
+	Description          string                    `json:"description,omitempty"`
+	Nullable             bool                      `json:"-"` // also allows null
+	Format               string                    `json:"format,omitempty"`
+	Properties           map[string]FormatProperty `json:"properties,omitempty"`
+	Enum                 []string                  `json:"enum,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	Items                *ItemProperty             `json:"items,omitempty"`
+	AdditionalProperties *FormatProperty           `json:"additionalProperties,omitempty"`
+	Minimum              *float64                  `json:"minimum,omitempty"`
+	Maximum              *float64                  `json:"maximum,omitempty"`
+	Pattern              string                    `json:"pattern,omitempty"`
+	Default              any                       `json:"default,omitempty"`
+	Examples             []any                     `json:"examples,omitempty"`
 }
 
+// FormatProperty is the schema of a property of an object.
+//
+// Nullable properties are encoded with a type array such as
+// ["string","null"]. Enum values of numeric properties are encoded as
+// numbers.
 type FormatProperty struct {
 	Type        string       `json:"type"`
 	Description string       `json:"description,omitempty"`
 	Enum        []string     `json:"enum,omitempty"`
 	Items       ItemProperty `json:"items,omitempty"`
+	// Nullable also allows null, e.g. for pointer fields.
+	Nullable bool `json:"-"`
+	// Format is a string format such as "date-time".
+	Format string `json:"format,omitempty"`
+	// Properties and Required describe nested objects.
+	Properties map[string]FormatProperty `json:"properties,omitempty"`
+	Required   []string                  `json:"required,omitempty"`
+	// AdditionalProperties is the schema of the values of maps.
+	AdditionalProperties *FormatProperty `json:"additionalProperties,omitempty"`
+	Minimum              *float64        `json:"minimum,omitempty"`
+	Maximum              *float64        `json:"maximum,omitempty"`
+	Pattern              string          `json:"pattern,omitempty"`
+	Default              any             `json:"default,omitempty"`
+	Examples             []any           `json:"examples,omitempty"`
 }
 
 type StructuredFormat struct {
 	Type       string                    `json:"type"`
 	Properties map[string]FormatProperty `json:"properties"`
 	Required   []string                  `json:"required,omitempty"`
+
+	// err is the error of StructToStructuredFormat, reported when the
+	// format is used.
+	err error
 }
 
 type ToolFunction struct {
@@ -94,11 +162,13 @@ type Tool struct {
 }
 
 type ToolCallFunction struct {
+	Index     int            `json:"index,omitempty"`
 	Name      string         `json:"name"`
 	Arguments map[string]any `json:"arguments"`
 }
 
 type ToolCall struct {
+	ID       string           `json:"id,omitempty"`
 	Function ToolCallFunction `json:"function"`
 }
 
@@ -106,12 +176,134 @@ type ToolSource interface {
 	ListTools() ([]Tool, error)
 }
 
+// KeepAlive sets how long the model stays loaded in memory after a Chat,
+// Generate or Embedding call, overriding Gollama.KeepAlive. A negative
+// duration keeps it loaded forever, and 0 unloads it right away.
+type KeepAlive time.Duration
+
+// Logprobs requests the log-probability of every generated token, plus the
+// TopN most likely alternatives for each one (up to 20). Pass it as a
+// ChatOption to Chat or Generate. It needs Ollama 0.12.11 or newer.
+type Logprobs struct {
+	TopN int
+}
+
+// Generate options
+
+// Suffix is the text after the completion, for fill-in-the-middle code
+// completion with Generate.
+type Suffix string
+
+// Raw disables the model prompt template in Generate, so the prompt is sent
+// to the model as it is.
+type Raw bool
+
+// Template overrides the prompt template of the model in Generate.
+type Template string
+
+// PromptContext is the context returned by a previous Generate call. Passing
+// it to Generate continues from there without sending the whole text again.
+type PromptContext []int
+
 // Output structs
 
 type ChatOuput struct {
-	Role           string     `json:"role"`
-	Content        string     `json:"content"`
-	ToolCalls      []ToolCall `json:"tool_calls"`
-	PromptTokens   int        `json:"prompt_tokens"`
-	ResponseTokens int        `json:"response_tokens"`
+	Role               string         `json:"role"`
+	Content            string         `json:"content"`
+	Thinking           string         `json:"thinking,omitempty"`
+	ToolCalls          []ToolCall     `json:"tool_calls"`
+	PromptTokens       int            `json:"prompt_tokens"`
+	ResponseTokens     int            `json:"response_tokens"`
+	Logprobs           []TokenLogprob `json:"logprobs,omitempty"`
+	Model              string         `json:"model"`
+	CreatedAt          time.Time      `json:"created_at"`
+	DoneReason         string         `json:"done_reason"`
+	TotalDuration      time.Duration  `json:"total_duration"`
+	LoadDuration       time.Duration  `json:"load_duration"`
+	PromptEvalDuration time.Duration  `json:"prompt_eval_duration"`
+	EvalDuration       time.Duration  `json:"eval_duration"`
+}
+
+// ChatChunk is a partial response delivered by ChatStream as it is generated.
+//
+// Content and Thinking hold only the text generated since the previous
+// chunk. The last chunk of a stream has Done set.
+type ChatChunk struct {
+	Content   string         `json:"content"`
+	Thinking  string         `json:"thinking,omitempty"`
+	ToolCalls []ToolCall     `json:"tool_calls"`
+	Logprobs  []TokenLogprob `json:"logprobs,omitempty"`
+	Done      bool           `json:"done"`
+}
+
+// Conversation structs
+
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool"
+)
+
+// Message is a single entry of a chat history.
+//
+// Images are kept as PromptImage references and are only encoded when the
+// message is sent, so a history stays small and can be stored as JSON.
+type Message struct {
+	Role      string        `json:"role"`
+	Content   string        `json:"content"`
+	Images    []PromptImage `json:"images,omitempty"`
+	ToolCalls []ToolCall    `json:"tool_calls,omitempty"`
+	// ToolName and ToolCallID tell which call a tool message answers.
+	ToolName   string `json:"tool_name,omitempty"`
+	ToolCallID string `json:"tool_call_id,omitempty"`
+}
+
+// GenerateOutput is the response of Generate.
+//
+// Context can be passed back to Generate as a PromptContext to continue the
+// completion.
+type GenerateOutput struct {
+	Response           string         `json:"response"`
+	Thinking           string         `json:"thinking,omitempty"`
+	Context            []int          `json:"context,omitempty"`
+	PromptTokens       int            `json:"prompt_tokens"`
+	ResponseTokens     int            `json:"response_tokens"`
+	Logprobs           []TokenLogprob `json:"logprobs,omitempty"`
+	Model              string         `json:"model"`
+	CreatedAt          time.Time      `json:"created_at"`
+	DoneReason         string         `json:"done_reason"`
+	TotalDuration      time.Duration  `json:"total_duration"`
+	LoadDuration       time.Duration  `json:"load_duration"`
+	PromptEvalDuration time.Duration  `json:"prompt_eval_duration"`
+	EvalDuration       time.Duration  `json:"eval_duration"`
+}
+
+// GenerateChunk is a partial response delivered by GenerateStream.
+type GenerateChunk struct {
+	Response string         `json:"response"`
+	Thinking string         `json:"thinking,omitempty"`
+	Logprobs []TokenLogprob `json:"logprobs,omitempty"`
+	Done     bool           `json:"done"`
+}
+
+// TokenLogprob is the log-probability of a generated token. TopLogprobs
+// holds the most likely alternatives at that position, when requested.
+type TokenLogprob struct {
+	Token       string         `json:"token"`
+	Logprob     float64        `json:"logprob"`
+	Bytes       []int          `json:"bytes,omitempty"`
+	TopLogprobs []TokenLogprob `json:"top_logprobs,omitempty"`
+}
+
+// CandidateScore is the score of a candidate completion computed by
+// ScoreCandidates.
+//
+// Exact is false when a token of the candidate was not among the most
+// likely alternatives; Logprob is then an upper bound of the real value.
+type CandidateScore struct {
+	Candidate string         `json:"candidate"`
+	Logprob   float64        `json:"logprob"`
+	Tokens    []TokenLogprob `json:"tokens"`
+	Exact     bool           `json:"exact"`
 }
@@ -1,5 +1,7 @@
 package gollama
 
+import "encoding/json"
+
 // Version
 
 type versionResponse struct {
@@ -29,11 +31,18 @@ type showRequest struct {
 	Model string `json:"model"`
 }
 
+// Ps
+
+type psResponse struct {
+	Models []RunningModel `json:"models"`
+}
+
 // Embeddings
 
 type embeddingsRequest struct {
-	Model  string `json:"model"`
-	Prompt string `json:"prompt"`
+	Model     string `json:"model"`
+	Prompt    string `json:"prompt"`
+	KeepAlive string `json:"keep_alive,omitempty"`
 }
 type embeddingsResponse struct {
 	Embedding []float64 `json:"embedding"`
@@ -42,26 +51,63 @@ type embeddingsResponse struct {
 // Chat
 
 type chatMessage struct {
-	Role    string   `json:"role"`
-	Content string   `json:"content"`
-	Images  []string `json:"images,omitempty"`
+	Role       string     `json:"role"`
+	Content    string     `json:"content"`
+	Images     []string   `json:"images,omitempty"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolName   string     `json:"tool_name,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
 }
 
-type chatOptionsRequest struct {
-	Seed          int     `json:"seed"`
-	Temperature   float64 `json:"temperature"`
-	TopK          int     `json:"top_k"`
-	TopP          float64 `json:"top_p"`
-	ContextLength int64   `json:"num_ctx,omitempty"`
+type chatRequest struct {
+	Model       string          `json:"model"`
+	Stream      bool            `json:"stream"`
+	Messages    []chatMessage   `json:"messages"`
+	Tools       *[]Tool         `json:"tools,omitempty"`
+	Format      json.RawMessage `json:"format,omitempty"`
+	Think       *bool           `json:"think,omitempty"`
+	KeepAlive   string          `json:"keep_alive,omitempty"`
+	Logprobs    bool            `json:"logprobs,omitempty"`
+	TopLogprobs int             `json:"top_logprobs,omitempty"`
+	Options     Options         `json:"options"`
 }
 
-type chatRequest struct {
-	Model    string             `json:"model"`
-	Stream   bool               `json:"stream"`
-	Messages []chatMessage      `json:"messages"`
-	Tools    *[]Tool            `json:"tools,omitempty"`
-	Format   *StructuredFormat  `json:"format,omitempty"`
-	Options  chatOptionsRequest `json:"options"`
+// Generate
+
+type generateRequest struct {
+	Model       string          `json:"model"`
+	Prompt      string          `json:"prompt"`
+	Suffix      string          `json:"suffix,omitempty"`
+	Images      []string        `json:"images,omitempty"`
+	Format      json.RawMessage `json:"format,omitempty"`
+	System      string          `json:"system,omitempty"`
+	Template    string          `json:"template,omitempty"`
+	Context     []int           `json:"context,omitempty"`
+	Stream      bool            `json:"stream"`
+	Raw         bool            `json:"raw,omitempty"`
+	Think       *bool           `json:"think,omitempty"`
+	KeepAlive   string          `json:"keep_alive,omitempty"`
+	Logprobs    bool            `json:"logprobs,omitempty"`
+	TopLogprobs int             `json:"top_logprobs,omitempty"`
+	Options     Options         `json:"options"`
+}
+
+type generateResponse struct {
+	Model              string         `json:"model"`
+	CreatedAt          string         `json:"created_at"`
+	Response           string         `json:"response"`
+	Thinking           string         `json:"thinking,omitempty"`
+	Done               bool           `json:"done"`
+	DoneReason         string         `json:"done_reason"`
+	Context            []int          `json:"context,omitempty"`
+	TotalDuration      int64          `json:"total_duration,omitempty"`
+	LoadDuration       int64          `json:"load_duration,omitempty"`
+	PromptEvalCount    int            `json:"prompt_eval_count,omitempty"`
+	PromptEvalDuration int64          `json:"prompt_eval_duration,omitempty"`
+	EvalCount          int            `json:"eval_count,omitempty"`
+	EvalDuration       int64          `json:"eval_duration,omitempty"`
+	Logprobs           []TokenLogprob `json:"logprobs,omitempty"`
+	Error              string         `json:"error,omitempty"`
 }
 
 // ResponseChat is the response from the Ollama API
@@ -69,6 +115,7 @@ type chatRequest struct {
 type messageResponse struct {
 	Role      string     `json:"role"`
 	Content   string     `json:"content"`
+	Thinking  string     `json:"thinking,omitempty"`
 	ToolCalls []ToolCall `json:"tool_calls"`
 }
 
@@ -84,4 +131,6 @@ type chatResponse struct {
 	PromptEvalDuration int64           `json:"prompt_eval_duration,omitempty"`
 	EvalCount          int             `json:"eval_count,omitempty"`
 	EvalDuration       int64           `json:"eval_duration,omitempty"`
+	Logprobs           []TokenLogprob  `json:"logprobs,omitempty"`
+	Error              string          `json:"error,omitempty"`
 }
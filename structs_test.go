@@ -1,8 +1,10 @@
 package gollama
 
 import (
+	"encoding/json"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestStructToStructuredFormat(t *testing.T) {
@@ -84,6 +86,12 @@ func TestChatOuput_DecodeContent(t *testing.T) {
 			wantResp: wantResp{Content: "last"},
 			wantErr:  false,
 		},
+		{
+			name:     "DecodeContent valid json followed by a citation",
+			o:        ChatOuput{Content: "```json\n{\"content\":\"cited\"}\n```\nSource: [1]"},
+			wantResp: wantResp{Content: "cited"},
+			wantErr:  false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -100,3 +108,157 @@ func TestChatOuput_DecodeContent(t *testing.T) {
 		})
 	}
 }
+
+func TestNewStructuredFormat(t *testing.T) {
+	type Base struct {
+		ID string `json:"id" required:"true"`
+	}
+	type Item struct {
+		Name  string  `json:"name" required:"true"`
+		Price float64 `json:"price" min:"0"`
+	}
+	type Order struct {
+		Base
+		Status   string            `json:"status" enum:"open,closed" default:"open"`
+		Priority int               `json:"priority" enum:"1,2,3" min:"1" max:"3" examples:"1,2"`
+		Code     string            `json:"code,omitempty" pattern:"^[A-Z]+$"`
+		Items    []Item            `json:"items" required:"true"`
+		Note     *string           `json:"note"`
+		Tags     map[string]string `json:"tags"`
+		Created  time.Time         `json:"created"`
+		Secret   string            `json:"-"`
+		internal string
+	}
+
+	tests := []struct {
+		name    string
+		v       any
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "Nested types and tags",
+			v:    &Order{},
+			want: `{"type":"object","properties":{` +
+				`"code":{"type":"string","pattern":"^[A-Z]+$"},` +
+				`"created":{"type":"string","format":"date-time"},` +
+				`"id":{"type":"string"},` +
+				`"items":{"type":"array","items":{"type":"object","properties":{"name":{"type":"string"},"price":{"type":"number","minimum":0}},"required":["name"]}},` +
+				`"note":{"type":["string","null"]},` +
+				`"priority":{"type":"integer","enum":[1,2,3],"minimum":1,"maximum":3,"examples":[1,2]},` +
+				`"status":{"type":"string","enum":["open","closed"],"default":"open"},` +
+				`"tags":{"type":"object","additionalProperties":{"type":"string"}}` +
+				`},"required":["id","items"]}`,
+		},
+		{
+			name: "Bytes",
+			v: struct {
+				Data []byte  `json:"data"`
+				Hash [4]byte `json:"hash"`
+			}{},
+			want: `{"type":"object","properties":{` +
+				`"data":{"type":"string"},` +
+				`"hash":{"type":"array","items":{"type":"integer"}}` +
+				`}}`,
+		},
+		{
+			name: "Tags on slices constrain the items",
+			v: struct {
+				Tags   []string `json:"tags" enum:"a,b"`
+				Scores []int    `json:"scores" min:"0" max:"10"`
+				Codes  []string `json:"codes" pattern:"^[A-Z]+$"`
+			}{},
+			want: `{"type":"object","properties":{` +
+				`"codes":{"type":"array","items":{"type":"string","pattern":"^[A-Z]+$"}},` +
+				`"scores":{"type":"array","items":{"type":"integer","minimum":0,"maximum":10}},` +
+				`"tags":{"type":"array","items":{"type":"string","enum":["a","b"]}}` +
+				`}}`,
+		},
+		{
+			name:    "Not a struct",
+			v:       []Item{},
+			wantErr: true,
+		},
+		{
+			name: "Unsupported type",
+			v: struct {
+				C chan int `json:"c"`
+			}{},
+			wantErr: true,
+		},
+		{
+			name: "Required with omitempty",
+			v: struct {
+				Code string `json:"code,omitempty" required:"true"`
+			}{},
+			wantErr: true,
+		},
+		{
+			name: "Invalid tag",
+			v: struct {
+				Name string `json:"name" min:"1"`
+			}{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewStructuredFormat(tt.v)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewStructuredFormat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if StructToStructuredFormat(tt.v).err == nil {
+					t.Errorf("StructToStructuredFormat() did not keep the error")
+				}
+				return
+			}
+
+			data, _ := json.Marshal(got)
+			if string(data) != tt.want {
+				t.Errorf("NewStructuredFormat() = %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewStructuredFormat_Recursive(t *testing.T) {
+	type Node struct {
+		Children []Node `json:"children"`
+	}
+
+	if _, err := NewStructuredFormat(Node{}); err == nil {
+		t.Error("NewStructuredFormat() should reject recursive types")
+	}
+}
+
+func TestFormatProperty_JSON(t *testing.T) {
+	data := `{"type":["integer","null"],"enum":[1,2],"items":{"type":"string"},"additionalProperties":false}`
+
+	var p FormatProperty
+	if err := json.Unmarshal([]byte(data), &p); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	want := FormatProperty{Type: "integer", Nullable: true, Enum: []string{"1", "2"}, Items: ItemProperty{Type: "string"}}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("decoded = %+v, want %+v", p, want)
+	}
+
+	encoded, _ := json.Marshal(FormatProperty{Type: "string"})
+	if string(encoded) != `{"type":"string"}` {
+		t.Errorf("encoded = %s, want the empty items omitted", encoded)
+	}
+
+	item := FormatProperty{Type: "string", Default: "a", Examples: []any{"a", "b"}}.item()
+	encoded, _ = json.Marshal(item)
+	if string(encoded) != `{"type":"string","default":"a","examples":["a","b"]}` {
+		t.Errorf("encoded item = %s, want default and examples", encoded)
+	}
+
+	var decoded ItemProperty
+	if err := json.Unmarshal(encoded, &decoded); err != nil || !reflect.DeepEqual(decoded, item) {
+		t.Errorf("decoded item = %+v, want %+v (%v)", decoded, item, err)
+	}
+}
@@ -0,0 +1,128 @@
+package gollama
+
+import "strings"
+
+const (
+	thinkOpenTag  = "<think>"
+	thinkCloseTag = "</think>"
+)
+
+// Think enables or disables the reasoning ("thinking") of models that
+// support it, such as deepseek-r1 or qwen3. Pass it as a ChatOption to Chat
+// or Generate.
+//
+// The reasoning is returned in the Thinking field of the output, separately
+// from the content.
+type Think bool
+
+// splitThinking separates the inline <think>...</think> section that older
+// Ollama servers leave at the start of the content. It returns the
+// reasoning and the content without it.
+//
+// Only a section at the start is reasoning, so an answer that mentions the
+// tags is left as it is. An opening tag without a closing one means the
+// rest is reasoning. When opened is set, as some model templates open the
+// section in the prompt when thinking is enabled, a closing tag without an
+// opening one means everything before it is reasoning.
+func splitThinking(content string, opened bool) (string, string) {
+	if !strings.Contains(content, thinkOpenTag) && !strings.Contains(content, thinkCloseTag) {
+		return "", content
+	}
+
+	if open, close := strings.Index(content, thinkOpenTag), strings.Index(content, thinkCloseTag); opened && close >= 0 && (open < 0 || close < open) {
+		thinking := content[:close]
+		return strings.TrimSpace(thinking), strings.TrimLeft(content[close+len(thinkCloseTag):], "\n")
+	}
+
+	s := thinkSplitter{}
+	thinking, rest := s.split(content)
+	t, c := s.flush()
+
+	return strings.TrimSpace(thinking + t), rest + c
+}
+
+// thinkSplitter separates the inline <think> section at the start of
+// streamed content, where a tag may be split across chunks.
+type thinkSplitter struct {
+	inThink bool
+	pending string
+	// done reports that the content is past the place where a section
+	// can be, so the rest is passed as it is.
+	done bool
+	// trim reports that the section just ended, and the newlines that
+	// follow it are dropped.
+	trim bool
+}
+
+// split returns the reasoning and the content found in the next chunk of
+// text. Text that could be the start of a tag is held back until the next
+// call.
+func (s *thinkSplitter) split(delta string) (string, string) {
+	var thinking, content strings.Builder
+
+	text := s.pending + delta
+	s.pending = ""
+
+	for text != "" {
+		switch {
+		case s.inThink:
+			if i := strings.Index(text, thinkCloseTag); i >= 0 {
+				thinking.WriteString(text[:i])
+				text = text[i+len(thinkCloseTag):]
+				s.inThink, s.done, s.trim = false, true, true
+				continue
+			}
+
+			keep := partialSuffix(text, thinkCloseTag)
+			thinking.WriteString(text[:len(text)-keep])
+			s.pending = text[len(text)-keep:]
+			return thinking.String(), content.String()
+
+		case s.done:
+			if s.trim {
+				text = strings.TrimLeft(text, "\n")
+				s.trim = text == ""
+			}
+			content.WriteString(text)
+			return thinking.String(), content.String()
+
+		default:
+			// Spaces before the section are held back with it.
+			trimmed := strings.TrimLeft(text, " \t\r\n")
+			switch {
+			case strings.HasPrefix(trimmed, thinkOpenTag):
+				text = trimmed[len(thinkOpenTag):]
+				s.inThink = true
+			case strings.HasPrefix(thinkOpenTag, trimmed):
+				s.pending = text
+				return thinking.String(), content.String()
+			default:
+				s.done = true
+			}
+		}
+	}
+
+	return thinking.String(), content.String()
+}
+
+// flush returns the text held back by split once the stream is over.
+func (s *thinkSplitter) flush() (string, string) {
+	pending := s.pending
+	s.pending = ""
+
+	if s.inThink {
+		return pending, ""
+	}
+	return "", pending
+}
+
+// partialSuffix returns the length of the longest suffix of text that is a
+// proper prefix of tag.
+func partialSuffix(text, tag string) int {
+	for n := len(tag) - 1; n > 0; n-- {
+		if strings.HasSuffix(text, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
@@ -0,0 +1,141 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestSplitThinking(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		opened       bool
+		wantThinking string
+		wantContent  string
+	}{
+		{
+			name:        "No thinking",
+			content:     "Hello",
+			wantContent: "Hello",
+		},
+		{
+			name:         "Inline block",
+			content:      "<think>\nThe user says hi.\n</think>\n\nHello!",
+			wantThinking: "The user says hi.",
+			wantContent:  "Hello!",
+		},
+		{
+			name:         "Opened by the template",
+			content:      "The user says hi.</think>Hello!",
+			opened:       true,
+			wantThinking: "The user says hi.",
+			wantContent:  "Hello!",
+		},
+		{
+			name:        "Closing tag without thinking",
+			content:     "Use the </think> tag to end it.",
+			wantContent: "Use the </think> tag to end it.",
+		},
+		{
+			name:        "Tags after the start",
+			content:     "Write <think>...</think> around it.",
+			opened:      true,
+			wantContent: "Write <think>...</think> around it.",
+		},
+		{
+			name:         "Truncated",
+			content:      "<think>The user says",
+			wantThinking: "The user says",
+			wantContent:  "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thinking, content := splitThinking(tt.content, tt.opened)
+			if thinking != tt.wantThinking || content != tt.wantContent {
+				t.Errorf("splitThinking() = %q, %q, want %q, %q", thinking, content, tt.wantThinking, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestThinkSplitter(t *testing.T) {
+	chunks := []string{"<thi", "nk>Let me", " think.</th", "ink>", "The answer", " is 4. <", "b>"}
+
+	var s thinkSplitter
+	var thinking, content string
+	for _, chunk := range chunks {
+		t, c := s.split(chunk)
+		thinking += t
+		content += c
+	}
+	t2, c2 := s.flush()
+	thinking += t2
+	content += c2
+
+	if thinking != "Let me think." || content != "The answer is 4. <b>" {
+		t.Errorf("thinkSplitter = %q, %q", thinking, content)
+	}
+
+	// Tags after the start of the content are left in it.
+	s = thinkSplitter{}
+	_, head := s.split("x = \"<thi")
+	_, tail := s.split("nk>\"")
+	if head+tail != "x = \"<think>\"" {
+		t.Errorf("thinkSplitter = %q", head+tail)
+	}
+}
+
+func TestGollama_Chat_Think(t *testing.T) {
+	c := newTestServer(t, "qwen3", func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Think == nil || !*req.Think {
+			t.Errorf("think = %v, want true", req.Think)
+		}
+		fmt.Fprint(w, `{"model":"qwen3","message":{"role":"assistant","content":"<think>2+2 is 4</think>\n`+"```json\\n{\\\"content\\\":\\\"4\\\"}\\n```"+`"},"done":true}`)
+	})
+
+	got, err := c.Chat(context.Background(), "what is 2 + 2?", Think(true))
+	if err != nil {
+		t.Fatalf("Gollama.Chat() error = %v", err)
+	}
+	if got.Thinking != "2+2 is 4" {
+		t.Errorf("Gollama.Chat() thinking = %q", got.Thinking)
+	}
+
+	var v struct {
+		Content string `json:"content"`
+	}
+	if err := got.DecodeContent(&v); err != nil || v.Content != "4" {
+		t.Errorf("ChatOuput.DecodeContent() = %+v, %v", v, err)
+	}
+}
+
+func TestGollama_ChatStream_Think(t *testing.T) {
+	c := newTestServer(t, "qwen3", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"model":"qwen3","message":{"role":"assistant","content":"","thinking":"Hmm"},"done":false}`)
+		fmt.Fprintln(w, `{"model":"qwen3","message":{"role":"assistant","content":"<think>inline</th"},"done":false}`)
+		fmt.Fprintln(w, `{"model":"qwen3","message":{"role":"assistant","content":"ink>4"},"done":false}`)
+		fmt.Fprintln(w, `{"model":"qwen3","message":{"role":"assistant","content":""},"done":true}`)
+	})
+
+	var thinking, content string
+	got, err := c.ChatStream(context.Background(), "what is 2 + 2?", func(chunk ChatChunk) error {
+		thinking += chunk.Thinking
+		content += chunk.Content
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Gollama.ChatStream() error = %v", err)
+	}
+	if thinking != "Hmminline" || content != "4" {
+		t.Errorf("Gollama.ChatStream() chunks = %q, %q", thinking, content)
+	}
+	if got.Thinking != "Hmminline" || got.Content != "4" {
+		t.Errorf("Gollama.ChatStream() = %+v", got)
+	}
+}
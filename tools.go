@@ -0,0 +1,184 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ToolHandler runs a tool with the arguments sent by the model, and returns
+// the result to send back to it.
+type ToolHandler func(ctx context.Context, arguments map[string]any) (string, error)
+
+// ToolRegistry holds tools together with the Go functions that run them.
+//
+// It is a ToolSource, so it can be passed to Chat as an option to offer its
+// tools to the model. The ToolCalls of the answer are then run with Call.
+type ToolRegistry struct {
+	mu    sync.RWMutex
+	tools map[string]registeredTool
+	names []string // registration order
+}
+
+type registeredTool struct {
+	tool    Tool
+	handler ToolHandler
+}
+
+// NewToolRegistry creates an empty ToolRegistry.
+func NewToolRegistry() *ToolRegistry {
+	return &ToolRegistry{
+		tools: make(map[string]registeredTool),
+	}
+}
+
+// Register adds a tool that is run by handler with the raw arguments. Use
+// RegisterTool to register a typed Go function instead.
+func (r *ToolRegistry) Register(tool Tool, handler ToolHandler) error {
+	name := tool.Function.Name
+	if name == "" {
+		return fmt.Errorf("tool name is empty")
+	}
+	if handler == nil {
+		return fmt.Errorf("tool %q has no handler", name)
+	}
+	if tool.Type == "" {
+		tool.Type = "function"
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tools[name]; ok {
+		return fmt.Errorf("tool %q is already registered", name)
+	}
+
+	r.tools[name] = registeredTool{tool: tool, handler: handler}
+	r.names = append(r.names, name)
+
+	return nil
+}
+
+// RegisterTool registers fn as a tool. The parameters schema is generated
+// from the Args struct with NewStructuredFormat, using its json,
+// description, required and other schema tags.
+//
+// When the tool is called, the arguments are validated and coerced like
+// ValidateArguments does, then decoded into Args. The Result is sent to the model as is if
+// it is a string, or else as JSON.
+func RegisterTool[Args, Result any](r *ToolRegistry, name, description string, fn func(context.Context, Args) (Result, error)) error {
+	var zero Args
+	if reflect.TypeOf(zero) == nil || reflect.TypeOf(zero).Kind() != reflect.Struct {
+		return fmt.Errorf("arguments of tool %q must be a struct, got %T", name, zero)
+	}
+
+	parameters, err := NewStructuredFormat(zero)
+	if err != nil {
+		return fmt.Errorf("arguments of tool %q: %w", name, err)
+	}
+
+	tool := Tool{
+		Type: "function",
+		Function: ToolFunction{
+			Name:        name,
+			Description: description,
+			Parameters:  parameters,
+		},
+	}
+
+	return r.Register(tool, func(ctx context.Context, arguments map[string]any) (string, error) {
+		var args Args
+		if err := decodeArguments(arguments, &args); err != nil {
+			return "", fmt.Errorf("invalid arguments for tool %q: %w", name, err)
+		}
+
+		result, err := fn(ctx, args)
+		if err != nil {
+			return "", err
+		}
+
+		return toolResultString(result)
+	})
+}
+
+// ListTools returns the registered tools, in registration order.
+func (r *ToolRegistry) ListTools() ([]Tool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tools := make([]Tool, 0, len(r.names))
+	for _, name := range r.names {
+		tools = append(tools, r.tools[name].tool)
+	}
+
+	return tools, nil
+}
+
+// Has reports whether a tool with the given name is registered.
+func (r *ToolRegistry) Has(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.tools[name]
+	return ok
+}
+
+// Call runs the tool requested by a ToolCall of the model.
+func (r *ToolRegistry) Call(ctx context.Context, call ToolCall) (string, error) {
+	return r.CallToolContext(ctx, call.Function.Name, call.Function.Arguments)
+}
+
+// CallTool runs the named tool with the given arguments.
+func (r *ToolRegistry) CallTool(name string, arguments map[string]any) (string, error) {
+	return r.CallToolContext(context.Background(), name, arguments)
+}
+
+// CallToolContext runs the named tool with the given arguments. The
+// arguments are first checked with ValidateArguments against the parameters
+// of the tool, so handlers only get valid (possibly coerced) arguments. The
+// context is passed to the handler.
+func (r *ToolRegistry) CallToolContext(ctx context.Context, name string, arguments map[string]any) (string, error) {
+	r.mu.RLock()
+	registered, ok := r.tools[name]
+	r.mu.RUnlock()
+
+	if !ok {
+		return "", fmt.Errorf("unknown tool %q", name)
+	}
+
+	arguments, err := ValidateArguments(arguments, registered.tool.Function.Parameters)
+	if err != nil {
+		return "", fmt.Errorf("invalid arguments for tool %q: %w", name, err)
+	}
+
+	return registered.handler(ctx, arguments)
+}
+
+// decodeArguments decodes validated arguments into v.
+func decodeArguments(arguments map[string]any, v any) error {
+	data, err := json.Marshal(arguments)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// toolResultString returns a tool result as the text sent to the model.
+func toolResultString(result any) (string, error) {
+	switch r := result.(type) {
+	case string:
+		return r, nil
+	case fmt.Stringer:
+		return r.String(), nil
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("error encoding tool result: %w", err)
+	}
+
+	return string(data), nil
+}
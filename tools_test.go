@@ -0,0 +1,120 @@
+package gollama
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestRegisterTool(t *testing.T) {
+	type sumArgs struct {
+		A float64 `json:"a" description:"first number" required:"true"`
+		B float64 `json:"b" description:"second number" required:"true"`
+	}
+	type sumResult struct {
+		Sum float64 `json:"sum"`
+	}
+
+	registry := NewToolRegistry()
+
+	err := RegisterTool(registry, "func_sum", "Sum two numbers", func(ctx context.Context, args sumArgs) (sumResult, error) {
+		return sumResult{Sum: args.A + args.B}, nil
+	})
+	if err != nil {
+		t.Fatalf("RegisterTool() error = %v", err)
+	}
+
+	err = RegisterTool(registry, "echo", "Echo a text", func(ctx context.Context, args struct {
+		Text  string `json:"text"`
+		Times int    `json:"times"`
+		Loud  bool   `json:"loud"`
+	}) (string, error) {
+		return fmt.Sprintf("%s x%d loud=%t", args.Text, args.Times, args.Loud), nil
+	})
+	if err != nil {
+		t.Fatalf("RegisterTool() error = %v", err)
+	}
+
+	tools, _ := registry.ListTools()
+	if len(tools) != 2 || tools[0].Function.Name != "func_sum" || tools[1].Function.Name != "echo" {
+		t.Fatalf("ListTools() = %+v", tools)
+	}
+	wantParams := StructuredFormat{Type: "object", Properties: map[string]FormatProperty{
+		"a": {Type: "number", Description: "first number"},
+		"b": {Type: "number", Description: "second number"},
+	}, Required: []string{"a", "b"}}
+	if !reflect.DeepEqual(tools[0].Function.Parameters, wantParams) {
+		t.Errorf("parameters = %+v, want %+v", tools[0].Function.Parameters, wantParams)
+	}
+
+	tests := []struct {
+		name    string
+		call    ToolCall
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "Typed arguments",
+			call: ToolCall{Function: ToolCallFunction{Name: "func_sum", Arguments: map[string]any{"a": 300.0, "b": 738.2}}},
+			want: `{"sum":1038.2}`,
+		},
+		{
+			name: "Quoted numbers",
+			call: ToolCall{Function: ToolCallFunction{Name: "func_sum", Arguments: map[string]any{"a": "1.5", "b": " 2"}}},
+			want: `{"sum":3.5}`,
+		},
+		{
+			name: "String result and coercion",
+			call: ToolCall{Function: ToolCallFunction{Name: "echo", Arguments: map[string]any{"text": 42.0, "times": "3", "loud": "true"}}},
+			want: "42 x3 loud=true",
+		},
+		{
+			name:    "Invalid argument",
+			call:    ToolCall{Function: ToolCallFunction{Name: "func_sum", Arguments: map[string]any{"a": "many"}}},
+			wantErr: true,
+		},
+		{
+			name:    "Unknown tool",
+			call:    ToolCall{Function: ToolCallFunction{Name: "missing"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := registry.Call(context.Background(), tt.call)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Call() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Call() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToolRegistry_Register(t *testing.T) {
+	registry := NewToolRegistry()
+	handler := func(ctx context.Context, arguments map[string]any) (string, error) {
+		return "ok", nil
+	}
+
+	if err := registry.Register(Tool{Function: ToolFunction{Name: "a"}}, handler); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := registry.Register(Tool{Function: ToolFunction{Name: "a"}}, handler); err == nil {
+		t.Error("Register() of a duplicate name should fail")
+	}
+	if err := registry.Register(Tool{Function: ToolFunction{Name: "b"}}, nil); err == nil {
+		t.Error("Register() without handler should fail")
+	}
+	if err := RegisterTool(registry, "c", "", func(ctx context.Context, n int) (int, error) { return n, nil }); err == nil {
+		t.Error("RegisterTool() with non-struct arguments should fail")
+	}
+
+	tools, _ := registry.ListTools()
+	if len(tools) != 1 || tools[0].Type != "function" {
+		t.Errorf("ListTools() = %+v", tools)
+	}
+}
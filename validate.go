@@ -0,0 +1,492 @@
+package gollama
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ValidationError lists every value that does not match a schema.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+// FieldError is a value that does not match a schema. Path locates it in
+// the validated value, e.g. "items[2].price", and is empty for the value
+// itself.
+type FieldError struct {
+	Path    string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		messages = append(messages, fe.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// ValidateArguments checks the arguments of a tool call against the
+// parameters schema of the tool: required fields, types, enums and the
+// items of arrays.
+//
+// Safe coercions are applied, such as the string "3" to a number, and the
+// coerced arguments are returned. The error is a *ValidationError listing
+// every problem, worded so it can be sent back to the model.
+func ValidateArguments(arguments map[string]any, parameters StructuredFormat) (map[string]any, error) {
+	schema, err := schemaMap(parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	if arguments == nil {
+		arguments = map[string]any{}
+	}
+
+	v := schemaValidator{coerce: true}
+	coerced := v.validate(arguments, schema, "")
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	return coerced.(map[string]any), nil
+}
+
+// Validate checks a JSON document against the format: required fields,
+// types, enums, bounds, patterns and nested objects and arrays. The error
+// is a *ValidationError with path-qualified errors such as
+// "items[2].price: expected number, got string".
+//
+// Nothing is coerced: use it to check what the model really returned.
+func (f StructuredFormat) Validate(data []byte) error {
+	value, err := decodeJSON(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.validate(value, false)
+	return err
+}
+
+// validate checks a JSON value decoded by decodeJSON against the format,
+// and returns it with the safe coercions applied if coerce is set.
+func (f StructuredFormat) validate(value any, coerce bool) (any, error) {
+	if f.err != nil {
+		return nil, fmt.Errorf("invalid structured format: %w", f.err)
+	}
+
+	schema, err := schemaMap(f)
+	if err != nil {
+		return nil, err
+	}
+
+	v := schemaValidator{coerce: coerce, useNumber: true}
+	value = v.validate(value, schema, "")
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// decodeValidated decodes the JSON found in the content into v, after
+// checking it against the format. Safe coercions are applied first, so an
+// answer with a number as a string is still decoded.
+func (o ChatOuput) decodeValidated(format StructuredFormat, v any) error {
+	data, err := o.jsonContent(JSONLast, '{')
+	if err != nil {
+		return err
+	}
+
+	value, err := decodeJSON(data)
+	if err != nil {
+		return err
+	}
+
+	value, err = format.validate(value, true)
+	if err != nil {
+		return err
+	}
+
+	data, err = json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("error decoding JSON: %w", err)
+	}
+
+	return nil
+}
+
+// decodeJSON decodes a JSON document into a generic value. Numbers are
+// kept as json.Number, so large integers are not rounded when the value
+// is encoded again.
+func decodeJSON(data []byte) (any, error) {
+	var raw json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("error decoding JSON: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return nil, fmt.Errorf("error decoding JSON: %w", err)
+	}
+
+	return value, nil
+}
+
+// schemaMap returns a schema as a generic JSON value, so every kind of
+// schema is validated the same way.
+func schemaMap(schema any) (map[string]any, error) {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding schema: %w", err)
+	}
+
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("error decoding schema: %w", err)
+	}
+
+	return m, nil
+}
+
+// schemaValidator validates JSON values against a subset of JSON Schema:
+// type, properties, required, additionalProperties, items, enum, minimum,
+// maximum and pattern.
+type schemaValidator struct {
+	// coerce converts values of the wrong type when there is a clear
+	// equivalent, instead of reporting them.
+	coerce bool
+	// useNumber makes coerced numbers json.Number, like the numbers of
+	// values decoded by decodeJSON.
+	useNumber bool
+	errors    []FieldError
+}
+
+// err returns the errors found, sorted by path, or nil.
+func (v *schemaValidator) err() error {
+	if len(v.errors) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(v.errors, func(i, j int) bool {
+		return v.errors[i].Path < v.errors[j].Path
+	})
+	return &ValidationError{Errors: v.errors}
+}
+
+func (v *schemaValidator) fail(path, format string, args ...any) {
+	v.errors = append(v.errors, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// validate checks value against schema and returns it, coerced if needed.
+func (v *schemaValidator) validate(value any, schema map[string]any, path string) any {
+	types := schemaTypes(schema["type"])
+
+	if len(types) > 0 && !matchesAny(value, types) {
+		coerced, ok := v.coerceTo(value, types)
+		if !ok {
+			v.fail(path, "expected %s, got %s", strings.Join(types, " or "), jsonType(value))
+			return value
+		}
+		value = coerced
+	}
+
+	if enum, ok := schema["enum"].([]any); ok && len(enum) > 0 && value != nil {
+		if !inEnum(value, enum) {
+			v.fail(path, "expected one of %s, got %s", enumList(enum), jsonString(value))
+			return value
+		}
+	}
+
+	switch val := value.(type) {
+	case map[string]any:
+		return v.validateObject(val, schema, path)
+	case []any:
+		items, _ := schema["items"].(map[string]any)
+		if len(items) == 0 || !isArraySchema(types) {
+			return val
+		}
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = v.validate(item, items, fmt.Sprintf("%s[%d]", path, i))
+		}
+		return out
+	case string:
+		if pattern, ok := schema["pattern"].(string); ok && pattern != "" {
+			re, err := regexp.Compile(pattern)
+			if err == nil && !re.MatchString(val) {
+				v.fail(path, "does not match pattern %q", pattern)
+			}
+		}
+	default:
+		if f, ok := toFloat(val); ok {
+			if minimum, ok := toFloat(schema["minimum"]); ok && f < minimum {
+				v.fail(path, "must be >= %v", minimum)
+			}
+			if maximum, ok := toFloat(schema["maximum"]); ok && f > maximum {
+				v.fail(path, "must be <= %v", maximum)
+			}
+		}
+	}
+
+	return value
+}
+
+func (v *schemaValidator) validateObject(obj map[string]any, schema map[string]any, path string) map[string]any {
+	properties, _ := schema["properties"].(map[string]any)
+	out := make(map[string]any, len(obj))
+
+	if required, ok := schema["required"].([]any); ok {
+		for _, r := range required {
+			name, _ := r.(string)
+			if _, ok := obj[name]; !ok {
+				v.fail(joinPath(path, name), "missing required field")
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := obj[key]
+
+		if property, ok := properties[key].(map[string]any); ok {
+			out[key] = v.validate(value, property, joinPath(path, key))
+			continue
+		}
+
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				v.fail(joinPath(path, key), "unknown field")
+			}
+		case map[string]any:
+			value = v.validate(value, additional, joinPath(path, key))
+		}
+		out[key] = value
+	}
+
+	return out
+}
+
+// coerceTo converts value to one of the types, if there is a clear
+// equivalent.
+func (v *schemaValidator) coerceTo(value any, types []string) (any, bool) {
+	if !v.coerce {
+		return nil, false
+	}
+
+	for _, t := range types {
+		switch t {
+		case "number", "integer":
+			s, ok := value.(string)
+			if !ok {
+				continue
+			}
+			s = strings.TrimSpace(s)
+			f, err := strconv.ParseFloat(s, 64)
+			if err != nil || (t == "integer" && f != math.Trunc(f)) {
+				continue
+			}
+			if v.useNumber && json.Valid([]byte(s)) {
+				return json.Number(s), true
+			}
+			return f, true
+		case "boolean":
+			if s, ok := value.(string); ok {
+				if b, err := strconv.ParseBool(strings.TrimSpace(s)); err == nil {
+					return b, true
+				}
+			}
+		case "string":
+			if n, ok := value.(json.Number); ok {
+				return n.String(), true
+			}
+			if f, ok := toFloat(value); ok {
+				return strconv.FormatFloat(f, 'f', -1, 64), true
+			}
+			if b, ok := value.(bool); ok {
+				return strconv.FormatBool(b), true
+			}
+		case "array", "object":
+			// Models sometimes send nested values as encoded JSON.
+			s, ok := value.(string)
+			if !ok {
+				continue
+			}
+			var decoded any
+			if v.useNumber {
+				decoded, _ = decodeJSON([]byte(s))
+			} else if err := json.Unmarshal([]byte(s), &decoded); err != nil {
+				decoded = nil
+			}
+			if decoded != nil && matches(decoded, t) {
+				return decoded, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// schemaTypes returns the types allowed by the "type" keyword, which is
+// either a string or an array of strings.
+func schemaTypes(t any) []string {
+	switch t := t.(type) {
+	case string:
+		if t == "" {
+			return nil
+		}
+		return []string{t}
+	case []any:
+		types := []string{}
+		for _, item := range t {
+			if s, ok := item.(string); ok && s != "" {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func isArraySchema(types []string) bool {
+	return len(types) == 0 || matchesAny([]any{}, types)
+}
+
+func matchesAny(value any, types []string) bool {
+	for _, t := range types {
+		if matches(value, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether value has the JSON schema type t.
+func matches(value any, t string) bool {
+	switch t {
+	case "null":
+		return value == nil
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := toFloat(value)
+		return ok
+	case "integer":
+		f, ok := toFloat(value)
+		return ok && f == math.Trunc(f)
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	}
+	// Unknown types are not checked.
+	return true
+}
+
+// toFloat returns the value of a JSON number.
+func toFloat(value any) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// jsonType returns the JSON type name of a value, for error messages.
+func jsonType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	if _, ok := toFloat(value); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func inEnum(value any, enum []any) bool {
+	for _, e := range enum {
+		if reflect.DeepEqual(value, e) {
+			return true
+		}
+		if f, ok := toFloat(value); ok {
+			if g, ok := toFloat(e); ok && f == g {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func enumList(enum []any) string {
+	values := make([]string, 0, len(enum))
+	for _, e := range enum {
+		values = append(values, jsonString(e))
+	}
+	return strings.Join(values, ", ")
+}
+
+func jsonString(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,137 @@
+package gollama
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValidateArguments(t *testing.T) {
+	parameters := StructuredFormat{
+		Type: "object",
+		Properties: map[string]FormatProperty{
+			"city":  {Type: "string"},
+			"days":  {Type: "integer"},
+			"unit":  {Type: "string", Enum: []string{"celsius", "fahrenheit"}},
+			"alert": {Type: "boolean"},
+			"hours": {Type: "array", Items: ItemProperty{Type: "number"}},
+		},
+		Required: []string{"city", "days"},
+	}
+
+	tests := []struct {
+		name      string
+		arguments map[string]any
+		want      map[string]any
+		wantErr   string
+	}{
+		{
+			name:      "Valid",
+			arguments: map[string]any{"city": "Paris", "days": 3.0, "unit": "celsius"},
+			want:      map[string]any{"city": "Paris", "days": 3.0, "unit": "celsius"},
+		},
+		{
+			name:      "Coercions",
+			arguments: map[string]any{"city": 42.0, "days": "3", "alert": "true", "hours": []any{"1.5", 2.0}},
+			want:      map[string]any{"city": "42", "days": 3.0, "alert": true, "hours": []any{1.5, 2.0}},
+		},
+		{
+			name:      "Array as encoded JSON",
+			arguments: map[string]any{"city": "Paris", "days": 1.0, "hours": "[1, 2]"},
+			want:      map[string]any{"city": "Paris", "days": 1.0, "hours": []any{1.0, 2.0}},
+		},
+		{
+			name:      "Missing required and wrong types",
+			arguments: map[string]any{"days": 2.5, "hours": []any{1.0, "soon"}},
+			wantErr:   "city: missing required field; days: expected integer, got number; hours[1]: expected number, got string",
+		},
+		{
+			name:      "Invented enum value",
+			arguments: map[string]any{"city": "Paris", "days": 1.0, "unit": "kelvin"},
+			wantErr:   `unit: expected one of "celsius", "fahrenheit", got "kelvin"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidateArguments(tt.arguments, parameters)
+			if tt.wantErr != "" {
+				var verr *ValidationError
+				if !errors.As(err, &verr) || err.Error() != tt.wantErr {
+					t.Fatalf("ValidateArguments() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ValidateArguments() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateArguments() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructuredFormat_Validate(t *testing.T) {
+	type item struct {
+		Name  string  `json:"name" required:"true"`
+		Price float64 `json:"price" required:"true" min:"0"`
+	}
+	type order struct {
+		Status string   `json:"status" required:"true" enum:"open,closed"`
+		Items  []item   `json:"items" required:"true"`
+		Note   *string  `json:"note"`
+		Tags   []string `json:"tags" enum:"gift,urgent"`
+	}
+
+	format, err := NewStructuredFormat(order{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{
+			name: "Valid",
+			data: `{"status":"open","items":[{"name":"tea","price":2.5}],"note":null,"tags":["gift"]}`,
+		},
+		{
+			name:    "Enum on slice items",
+			data:    `{"status":"open","items":[],"tags":["gift","late"]}`,
+			wantErr: `tags[1]: expected one of "gift", "urgent", got "late"`,
+		},
+		{
+			name:    "Path-qualified errors",
+			data:    `{"status":"pending","items":[{"name":"tea","price":2.5},{"name":"cake","price":-1},{"name":"pie","price":"3"}]}`,
+			wantErr: `items[1].price: must be >= 0; items[2].price: expected number, got string; status: expected one of "open", "closed", got "pending"`,
+		},
+		{
+			name:    "Missing fields",
+			data:    `{"items":[{}]}`,
+			wantErr: "items[0].name: missing required field; items[0].price: missing required field; status: missing required field",
+		},
+		{
+			name:    "Not JSON",
+			data:    `{"status":`,
+			wantErr: "error decoding JSON: unexpected end of JSON input",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := format.Validate([]byte(tt.data))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Validate() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}